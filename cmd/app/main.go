@@ -12,9 +12,10 @@ import (
 
 	"github.com/box1bs/monocle/configs"
 	"github.com/box1bs/monocle/internal/app/indexer"
-	"github.com/box1bs/monocle/internal/app/indexer/textHandling"
+	"github.com/box1bs/monocle/internal/app/embedding"
+	"github.com/box1bs/monocle/internal/app/ranker"
 	"github.com/box1bs/monocle/internal/app/searcher"
-	"github.com/box1bs/monocle/internal/model"
+	"github.com/box1bs/monocle/internal/app/server"
 	"github.com/box1bs/monocle/internal/repository"
 	"github.com/box1bs/monocle/logs/logger"
 )
@@ -23,6 +24,10 @@ func main() {
 	var (
 		configFile = flag.String("config", "configs/search_config.json", "Path to configuration file")
 		logFile    = flag.String("log", "logs/indexedURLs.txt", "Path to log file")
+		modelFile  = flag.String("model", "", "Path to learned ranking model, empty disables reranking")
+		rerankTop  = flag.Int("rerank-top", 50, "Number of top candidates reranked by the learned model")
+		serve      = flag.Bool("serve", false, "Serve search over REST instead of the interactive CLI")
+		srvPort    = flag.Int("srv-port", 50051, "REST server port")
 	)
 	flag.Parse()
 
@@ -36,6 +41,9 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
+	if err := ir.SetEmbeddingEncoding(cfg.Embedding.Storage); err != nil {
+		panic(err)
+	}
 
 	file, err := os.Create(*logFile)
 	if err != nil {
@@ -61,8 +69,31 @@ func main() {
 		os.Exit(1)
 	}()
 
-	vec := textHandling.NewVectorizer()
+	vec, err := embedding.New(cfg.Embedding)
+	if err != nil {
+		panic(err)
+	}
+	vec = embedding.NewCache(vec, cfg.Embedding, ir)
 	i := indexer.NewIndexer(ir, vec, logger, 2, 3)
+	i.SetChunking(cfg.Embedding.ChunkWords, cfg.Embedding.ChunkOverlap)
+	if err := i.SetAnalysis(cfg.Analysis); err != nil {
+		panic(err)
+	}
+	if err := i.LoadSpelling(); err != nil {
+		panic(err)
+	}
+	if err := i.CheckEmbeddingDimension(); err != nil {
+		panic(err)
+	}
+	if err := i.CheckStemmerVersion(); err != nil {
+		panic(err)
+	}
+	if err := i.CheckEmbeddingVersion(embedding.Version(cfg.Embedding)); err != nil {
+		panic(err)
+	}
+	if err := i.LoadVectorIndex(cfg.VectorIndex); err != nil {
+		panic(err)
+	}
 	i.Index(cfg, ctx)
 
 	count, err := i.GetDocumentsCount()
@@ -70,10 +101,51 @@ func main() {
 		panic(err)
 	}
 
-	fmt.Printf("Index built with %d documents. Enter search queries (Ctrl+C to exit):\n", count)
-
 	s := searcher.NewSearcher(i, vec)
+	if err := s.LoadPipelines(cfg.Ranking); err != nil {
+		panic(err)
+	}
+	if err := s.LoadSuggestions(); err != nil {
+		panic(err)
+	}
+	if err := s.SetFeedback(ir); err != nil {
+		panic(err)
+	}
+	if !cfg.QueryLog.Disabled {
+		retention, err := cfg.QueryLog.RetentionDuration()
+		if err != nil {
+			panic(err)
+		}
+		ir.SetQueryLogRetention(retention)
+		s.SetQueryLog(ir)
+	}
+	if *modelFile != "" {
+		m, err := ranker.Load(*modelFile, searcher.FeatureNames)
+		if err != nil {
+			panic(err)
+		}
+		s.SetRanker(m, *rerankTop)
+	}
+
+	if *serve {
+		fmt.Printf("Index built with %d documents. Serving search on port %d\n", count, *srvPort)
+		if err := server.NewServer(*srvPort, s).Run(); err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	fmt.Printf("Index built with %d documents. Enter search queries (Ctrl+C to exit):\n", count)
+	fmt.Printf("Ranking pipelines: %s. Prefix a query with @name to select one, :explain toggles explanations.\n", strings.Join(s.Pipelines(), ", "))
+	fmt.Println("Retrieval modes: lexical, semantic, hybrid. Switch with :retrieval mode, :fusion rrf|convex selects hybrid fusion.")
+	fmt.Printf("Query languages: %s. Fix one with :lang code, :lang auto detects it per query.\n", strings.Join(i.Languages(), ", "))
+	fmt.Println("Emails, urls, ip addresses and numbers match exactly, email:, url:, ip: and num: restrict a value to one of them.")
+	fmt.Println(":suggest prefix lists completions of a prefix.")
 
+	explain := false
+	retrieval := searcher.RetrievalLexical
+	fusion := searcher.FusionRRF
+	lang := ""
 	reader := bufio.NewReader(os.Stdin)
 	for {
 		fmt.Print("> ")
@@ -82,21 +154,108 @@ func main() {
 		if query == "q" {
 			return
 		}
+		if query == ":explain" {
+			explain = !explain
+			fmt.Printf("explain: %v\n", explain)
+			continue
+		}
+		if mode, ok := strings.CutPrefix(query, ":retrieval "); ok {
+			retrieval = strings.TrimSpace(mode)
+			fmt.Printf("retrieval: %s\n", retrieval)
+			continue
+		}
+		if method, ok := strings.CutPrefix(query, ":fusion "); ok {
+			fusion = strings.TrimSpace(method)
+			fmt.Printf("fusion: %s\n", fusion)
+			continue
+		}
+		if prefix, ok := strings.CutPrefix(query, ":suggest "); ok {
+			completions, err := s.Suggest(prefix, 10)
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			for _, c := range completions {
+				fmt.Printf("  %-40s %d\n", c.Text, c.Weight)
+			}
+			continue
+		}
+		if code, ok := strings.CutPrefix(query, ":lang "); ok {
+			if lang = strings.TrimSpace(code); lang == "auto" {
+				lang = ""
+			}
+			fmt.Printf("lang: %s\n", strings.TrimSpace(code))
+			continue
+		}
+		params := searcher.Params{Quorum: searcher.DefaultQuorum, MaxLen: 100, Retrieval: retrieval, Fusion: fusion, Language: lang, Explain: explain}
+		if strings.HasPrefix(query, "@") {
+			name, rest, _ := strings.Cut(query[1:], " ")
+			params.Pipeline, query = name, strings.TrimSpace(rest)
+		}
 		t := time.Now()
-		Present(s.Search(query, 0.01, 100))
+		res, err := s.SearchCorrected(query, params)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		if res.Corrected != "" {
+			fmt.Printf("Did you mean: %s? Prefix the query with %s to search it as typed.\n", res.Corrected, searcher.LiteralPrefix)
+		}
+		Present(res.Hits)
 		fmt.Printf("--Search time: %v--\n", time.Since(t))
 	}
 }
 
-func Present(docs []*model.Document) {
-	if len(docs) == 0 {
+func Present(hits []searcher.Hit) {
+	if len(hits) == 0 {
 		fmt.Println("No results found.")
 		return
 	}
 	
-	fmt.Printf("Found %d results:\n", len(docs))
-	for i, doc := range docs {
-		fmt.Printf("%d. URL: %s\n\n", 
-			i+1, doc.URL)
+	fmt.Printf("Found %d results:\n", len(hits))
+	for i, hit := range hits {
+		fmt.Printf("%d. URL: %s\n", 
+			i+1, hit.Doc.URL)
+		if hit.Corrected {
+			fmt.Println("   (found by the corrected query)")
+		}
+		if hit.Passage != nil {
+			fmt.Printf("   %s\n", strings.Join(strings.Fields(hit.Passage.Marked("\033[1m", "\033[0m")), " "))
+		}
+		if hit.Explanation != nil {
+			presentExplanation(hit.Explanation)
+		}
+		fmt.Println()
+	}
+}
+
+func presentExplanation(e *searcher.Explanation) {
+	fmt.Printf("   position %d, score %.4f\n", e.Position, e.Score)
+	for _, t := range e.Terms {
+		term := t.Term
+		if t.Weight < 1 {
+			term += fmt.Sprintf(" (x%.2f)", t.Weight)
+		}
+		if t.CorrectedFrom != "" {
+			term += fmt.Sprintf(" (for %s)", t.CorrectedFrom)
+		} else if len(t.Suggestions) > 0 {
+			term += fmt.Sprintf(" (did you mean %s?)", strings.Join(t.Suggestions, ", "))
+		}
+		if !t.Matched {
+			fmt.Printf("   term %-20s not matched (df=%d)\n", term, t.DocFreq)
+			continue
+		}
+		fmt.Printf("   term %-20s tf=%d df=%d idf=%.4f tf-idf=%.4f bm25=%.4f header=%v\n", term, t.TF, t.DocFreq, t.IDF, t.TfIdf, t.BM25, t.InHeader)
+	}
+	fmt.Printf("   tf-idf=%.4f bm25=%.4f cosine=%.4f euclidean=%.4f coverage=%.2f density=%.4f words=%d header=%v fusion=%.4f\n",
+		e.TfIdf, e.BM25, e.Cosine, e.Euclidean, e.QueryCoverage, e.QueryDensity, e.IncludesWords, e.HasWordInHeader, e.Fusion)
+	if e.Passage != nil {
+		fmt.Printf("   best chunk %d, bytes %d-%d, similarity=%.4f\n", e.BestChunk, e.Passage.Start, e.Passage.End, e.Passage.Similarity)
 	}
-}
\ No newline at end of file
+	if e.Clicks > 0 {
+		fmt.Printf("   clicks=%.4f query-clicks=%.4f dwell=%.1fs\n", e.Clicks, e.QueryClicks, e.Dwell)
+	}
+	for _, sig := range e.Signals {
+		fmt.Printf("   signal %-16s value=%.4f weight=%.2f contribution=%.4f\n", sig.Name, sig.Value, sig.Weight, sig.Contribution)
+	}
+}
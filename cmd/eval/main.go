@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/box1bs/monocle/configs"
+	"github.com/box1bs/monocle/internal/app/embedding"
+	"github.com/box1bs/monocle/internal/app/evaluation"
+	"github.com/box1bs/monocle/internal/app/indexer"
+	"github.com/box1bs/monocle/internal/app/ranker"
+	"github.com/box1bs/monocle/internal/app/searcher"
+	"github.com/box1bs/monocle/internal/repository"
+	"github.com/box1bs/monocle/logs/logger"
+)
+
+type run struct {
+	name 		string
+	pipeline 	string
+	retrieval 	string
+	s 			*searcher.Searcher
+	metrics 	map[string]evaluation.Metrics
+}
+
+func main() {
+	if err := evaluate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// evaluate returns errors of the compared configurations so the temporary index is still removed.
+func evaluate() error {
+	var (
+		indexPath 	= flag.String("index", "index/badger", "Path to badger index, ignored when -fixtures is set")
+		fixtures 	= flag.String("fixtures", "", "Directory of local html files to build a temporary index from")
+		qrelsFile 	= flag.String("qrels", "", "Path to TREC qrels file, document ids are urls or fixture paths")
+		queryFile 	= flag.String("queries", "", "Path to queries file")
+		k 			= flag.Int("k", 10, "Cutoff for nDCG, precision and recall")
+		quorum 		= flag.Float64("quorum", searcher.DefaultQuorum, "Minimal length normalised tf-idf of a candidate")
+		maxLen 		= flag.Int("max", 100, "Number of results retrieved per query")
+		configFile 	= flag.String("config", "configs/search_config.json", "Path to configuration file with ranking pipelines")
+		pipelineA 	= flag.String("pipeline-a", "", "Ranking pipeline of configuration A, empty uses the configured default")
+		pipelineB 	= flag.String("pipeline-b", "", "Ranking pipeline of configuration B")
+		modelA 		= flag.String("model-a", "", "Learned ranking model of configuration A")
+		modelB 		= flag.String("model-b", "", "Learned ranking model of configuration B")
+		rerankTop 	= flag.Int("rerank-top", 50, "Number of top candidates reranked by a learned model")
+		provider 	= flag.String("embedder", "", "Overrides the configured embedding provider, e.g. hashing for offline fixture runs")
+		retrievalA 	= flag.String("retrieval-a", "", "Candidate retrieval of configuration A: lexical, semantic or hybrid")
+		retrievalB 	= flag.String("retrieval-b", "", "Candidate retrieval of configuration B")
+		fusion 		= flag.String("fusion", "", "Fusion of hybrid retrieval: rrf or convex")
+		vecWeight 	= flag.Float64("vector-weight", 0, "Share of the vector list in convex fusion, 0 uses the default")
+	)
+	flag.Parse()
+
+	if *qrelsFile == "" || *queryFile == "" {
+		return errors.New("qrels and queries files are required")
+	}
+	qrels, err := evaluation.LoadQrels(*qrelsFile)
+	if err != nil {
+		panic(err)
+	}
+	queries, err := evaluation.LoadQueries(*queryFile)
+	if err != nil {
+		panic(err)
+	}
+
+	cfg, err := configs.UploadLocalConfiguration(*configFile)
+	if err != nil {
+		panic(err)
+	}
+	if *provider != "" {
+		cfg.Embedding.Provider = *provider
+	}
+
+	path := *indexPath
+	if *fixtures != "" {
+		path, err = os.MkdirTemp("", "monocle-eval-")
+		if err != nil {
+			panic(err)
+		}
+		defer os.RemoveAll(path)
+	}
+
+	ir, err := repository.NewIndexRepository(path)
+	if err != nil {
+		panic(err)
+	}
+	defer ir.DB.Close()
+	if err := ir.SetEmbeddingEncoding(cfg.Embedding.Storage); err != nil {
+		panic(err)
+	}
+
+	logger, err := logger.NewAsyncLogger(os.Stderr)
+	if err != nil {
+		panic(err)
+	}
+	defer logger.Close()
+
+	vec, err := embedding.New(cfg.Embedding)
+	if err != nil {
+		panic(err)
+	}
+	vec = embedding.NewCache(vec, cfg.Embedding, ir)
+	i := indexer.NewIndexer(ir, vec, logger, 2, 3)
+	i.SetChunking(cfg.Embedding.ChunkWords, cfg.Embedding.ChunkOverlap)
+	if err := i.SetAnalysis(cfg.Analysis); err != nil {
+		panic(err)
+	}
+	if err := i.LoadSpelling(); err != nil {
+		panic(err)
+	}
+	if err := i.CheckEmbeddingDimension(); err != nil {
+		panic(err)
+	}
+	if err := i.CheckStemmerVersion(); err != nil {
+		panic(err)
+	}
+	if err := i.CheckEmbeddingVersion(embedding.Version(cfg.Embedding)); err != nil {
+		panic(err)
+	}
+	if err := i.LoadVectorIndex(cfg.VectorIndex); err != nil {
+		panic(err)
+	}
+
+	if *fixtures != "" {
+		count, err := i.IndexLocalFiles(context.Background(), *fixtures)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Printf("Fixture index built with %d documents\n\n", count)
+	}
+
+	runs := []*run{newRun("A", *pipelineA, *retrievalA, *modelA, searcher.NewSearcher(i, vec), cfg.Ranking, *rerankTop)}
+	if *pipelineB != "" || *retrievalB != "" || *modelB != "" {
+		runs = append(runs, newRun("B", *pipelineB, *retrievalB, *modelB, searcher.NewSearcher(i, vec), cfg.Ranking, *rerankTop))
+	}
+
+	params := func(r *run) searcher.Params {
+		return searcher.Params{Quorum: *quorum, MaxLen: *maxLen, Pipeline: r.pipeline, Retrieval: r.retrieval, Fusion: *fusion, VectorWeight: *vecWeight}
+	}
+	for _, r := range runs {
+		if err := r.s.Validate(params(r)); err != nil {
+			return fmt.Errorf("configuration %s: %w", r.name, err)
+		}
+	}
+
+	for _, r := range runs {
+		r.metrics = map[string]evaluation.Metrics{}
+		for _, q := range queries {
+			judged, ok := qrels[q.ID]
+			if !ok {
+				continue
+			}
+			ranked := []string{}
+			hits, err := r.s.SearchWith(q.Text, params(r))
+			if err != nil {
+				return fmt.Errorf("configuration %s, query %s: %w", r.name, q.ID, err)
+			}
+			for _, hit := range hits {
+				ranked = append(ranked, hit.Doc.URL)
+			}
+			r.metrics[q.ID] = evaluation.Evaluate(ranked, judged, *k)
+		}
+	}
+
+	fmt.Printf("%-44s %8s %8s %8s %8s %8s\n", "config", fmt.Sprintf("nDCG@%d", *k), "MAP", "MRR", fmt.Sprintf("P@%d", *k), fmt.Sprintf("R@%d", *k))
+	for _, r := range runs {
+		all := make([]evaluation.Metrics, 0, len(r.metrics))
+		for _, m := range r.metrics {
+			all = append(all, m)
+		}
+		m := evaluation.Mean(all)
+		fmt.Printf("%-44s %8.4f %8.4f %8.4f %8.4f %8.4f\n", r.name, m.NDCG, m.AP, m.RR, m.Precision, m.Recall)
+	}
+
+	if len(runs) == 2 {
+		printDiff(runs[0], runs[1], queries, *k)
+	}
+	return nil
+}
+
+func newRun(label, pipeline, retrieval, modelPath string, s *searcher.Searcher, ranking configs.RankingConfig, rerankTop int) *run {
+	if err := s.LoadPipelines(ranking); err != nil {
+		panic(err)
+	}
+	desc := "pipeline " + pipeline
+	if pipeline == "" {
+		desc = "default pipeline"
+		if retrieval == searcher.RetrievalHybrid {
+			desc = "fusion"
+		}
+	}
+	if retrieval != "" {
+		desc += ", " + retrieval + " retrieval"
+	}
+	if modelPath != "" {
+		m, err := ranker.Load(modelPath, searcher.FeatureNames)
+		if err != nil {
+			panic(err)
+		}
+		s.SetRanker(m, rerankTop)
+		desc += ", " + modelPath
+	}
+	return &run{name: label + " (" + desc + ")", pipeline: pipeline, retrieval: retrieval, s: s}
+}
+
+func printDiff(a, b *run, queries []evaluation.Query, k int) {
+	type row struct {
+		query 	evaluation.Query
+		a, b 	float64
+	}
+	rows := []row{}
+	for _, q := range queries {
+		ma, ok := a.metrics[q.ID]
+		if !ok {
+			continue
+		}
+		rows = append(rows, row{query: q, a: ma.NDCG, b: b.metrics[q.ID].NDCG})
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].b - rows[i].a < rows[j].b - rows[j].a
+	})
+
+	better, worse := 0, 0
+	fmt.Printf("\nPer query nDCG@%d, A vs B:\n", k)
+	fmt.Printf("%-10s %8s %8s %8s  %s\n", "qid", "A", "B", "delta", "query")
+	for _, r := range rows {
+		delta := r.b - r.a
+		switch {
+		case delta > 1e-9:
+			better++
+		case delta < -1e-9:
+			worse++
+		}
+		fmt.Printf("%-10s %8.4f %8.4f %+8.4f  %s\n", r.query.ID, r.a, r.b, delta, r.query.Text)
+	}
+	fmt.Printf("\nB is better on %d, worse on %d, equal on %d queries\n", better, worse, len(rows) - better - worse)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/box1bs/monocle/internal/repository"
+)
+
+// migrate moves document embeddings of an existing index out of the document records,
+// optionally rewrites all stored embeddings in another encoding, moves the n-gram
+// word lists to set keys and rebuilds the corpus statistics.
+func main() {
+	var (
+		indexPath 	= flag.String("index", "index/badger", "Path to badger index")
+		encoding 	= flag.String("encoding", repository.Float32Encoding, "Encoding of written embeddings: float32 or int8")
+		reencode 	= flag.Bool("reencode", false, "Rewrite embeddings already stored in another encoding")
+	)
+	flag.Parse()
+
+	ir, err := repository.NewIndexRepository(*indexPath)
+	if err != nil {
+		panic(err)
+	}
+	defer ir.DB.Close()
+
+	if err := ir.SetEmbeddingEncoding(*encoding); err != nil {
+		panic(err)
+	}
+	moved, reencoded, err := ir.MigrateEmbeddings(*reencode)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("Moved embeddings of %d documents, reencoded %d records as %s\n", moved, reencoded, *encoding)
+	nGrams, err := ir.MigrateNGrams()
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("Moved %d n-grams to set keys\n", nGrams)
+	docs, err := ir.RebuildCorpusStats()
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("Rebuilt corpus statistics of %d documents\n", docs)
+}
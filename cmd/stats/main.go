@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/box1bs/monocle/internal/app/analytics"
+	"github.com/box1bs/monocle/internal/repository"
+)
+
+// stats reports the most frequent queries, the queries that found nothing and search
+// latency percentiles from the query log of an index.
+func main() {
+	var (
+		indexPath 	= flag.String("index", "index/badger", "Path to badger index")
+		since 		= flag.Duration("since", 0, "Only count queries of this last period, 0 counts the whole log")
+		topN 		= flag.Int("top", 10, "Number of queries listed per report")
+	)
+	flag.Parse()
+
+	ir, err := repository.NewIndexRepository(*indexPath)
+	if err != nil {
+		panic(err)
+	}
+	defer ir.DB.Close()
+
+	from := time.Time{}
+	if *since > 0 {
+		from = time.Now().Add(-*since)
+	}
+	entries, err := ir.QueryLog(from)
+	if err != nil {
+		panic(err)
+	}
+	st := analytics.Summarize(entries, *topN)
+
+	fmt.Printf("Queries: %d (%d distinct), %d without results, %d corrected\n", st.Queries, st.Distinct, st.ZeroResults, st.Corrected)
+	fmt.Printf("Latency: p50 %v, p90 %v, p99 %v, max %v\n", st.P50, st.P90, st.P99, st.Max)
+	fmt.Println("\nTop queries:")
+	for i, q := range st.Top {
+		fmt.Printf("%3d. %-50s %d\n", i + 1, q.Query, q.Count)
+	}
+	fmt.Println("\nTop queries without results:")
+	for i, q := range st.TopZero {
+		fmt.Printf("%3d. %-50s %d\n", i + 1, q.Query, q.Count)
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/box1bs/monocle/configs"
+	"github.com/box1bs/monocle/internal/app/embedding"
+	"github.com/box1bs/monocle/internal/app/indexer"
+	"github.com/box1bs/monocle/internal/app/ranker"
+	"github.com/box1bs/monocle/internal/app/searcher"
+	"github.com/box1bs/monocle/internal/repository"
+	"github.com/box1bs/monocle/logs/logger"
+)
+
+// judgments file format: one "query<TAB>url<TAB>grade" entry per line,
+// candidates that have no judgment for their query are treated as grade 0.
+func main() {
+	var (
+		indexPath 	= flag.String("index", "index/badger", "Path to badger index")
+		configFile 	= flag.String("config", "configs/search_config.json", "Path to configuration file")
+		judgments 	= flag.String("judgments", "", "Path to judged query set")
+		out 		= flag.String("out", "configs/ranking_model.json", "Path to write trained model")
+		quorum 		= flag.Float64("quorum", searcher.DefaultQuorum, "Minimal length normalised tf-idf of a candidate")
+		lambda 		= flag.Float64("lambda", 1.0, "L2 regularization strength")
+		useClicks 	= flag.Bool("clicks", false, "Judge results by reported clicks where the judgments file has no grade")
+	)
+	flag.Parse()
+
+	if *judgments == "" && !*useClicks {
+		fmt.Fprintln(os.Stderr, "judgments file or -clicks is required")
+		os.Exit(2)
+	}
+
+	judged := map[string]map[string]float64{}
+	var err error
+	if *judgments != "" {
+		if judged, err = loadJudgments(*judgments); err != nil {
+			panic(err)
+		}
+	}
+
+	cfg, err := configs.UploadLocalConfiguration(*configFile)
+	if err != nil {
+		panic(err)
+	}
+
+	ir, err := repository.NewIndexRepository(*indexPath)
+	if err != nil {
+		panic(err)
+	}
+	defer ir.DB.Close()
+	if err := ir.SetEmbeddingEncoding(cfg.Embedding.Storage); err != nil {
+		panic(err)
+	}
+
+	logger, err := logger.NewAsyncLogger(os.Stdout)
+	if err != nil {
+		panic(err)
+	}
+	defer logger.Close()
+
+	vec, err := embedding.New(cfg.Embedding)
+	if err != nil {
+		panic(err)
+	}
+	vec = embedding.NewCache(vec, cfg.Embedding, ir)
+	i := indexer.NewIndexer(ir, vec, logger, 2, 3)
+	if err := i.SetAnalysis(cfg.Analysis); err != nil {
+		panic(err)
+	}
+	if err := i.LoadSpelling(); err != nil {
+		panic(err)
+	}
+	if err := i.CheckEmbeddingDimension(); err != nil {
+		panic(err)
+	}
+	if err := i.CheckStemmerVersion(); err != nil {
+		panic(err)
+	}
+	if err := i.CheckEmbeddingVersion(embedding.Version(cfg.Embedding)); err != nil {
+		panic(err)
+	}
+	s := searcher.NewSearcher(i, vec)
+	if err := s.SetFeedback(ir); err != nil {
+		panic(err)
+	}
+	if *useClicks {
+		n, err := addClickJudgments(ir, judged)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Printf("%d results judged by clicks\n", n)
+	}
+
+	samples := []ranker.Sample{}
+	for query, grades := range judged {
+		rows, err := s.Features(query, *quorum)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "query %q: %v\n", query, err)
+			continue
+		}
+		matched := 0
+		for _, row := range rows {
+			grade, ok := grades[row.Doc.URL]
+			if ok {
+				matched++
+			}
+			samples = append(samples, ranker.Sample{Features: row.Features, Label: grade})
+		}
+		fmt.Printf("query %q: %d candidates, %d judged\n", query, len(rows), matched)
+	}
+
+	model, err := ranker.TrainLinear(searcher.FeatureNames, samples, *lambda)
+	if err != nil {
+		panic(err)
+	}
+	if err := model.Save(*out); err != nil {
+		panic(err)
+	}
+
+	fmt.Printf("Trained on %d samples, model saved to %s\n", len(samples), *out)
+	for i, name := range model.Features {
+		fmt.Printf("  %-16s %.4f\n", name, model.Weights[i])
+	}
+	fmt.Printf("  %-16s %.4f\n", "bias", model.Bias)
+}
+
+func loadJudgments(path string) (map[string]map[string]float64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	judged := map[string]map[string]float64{}
+	scanner := bufio.NewScanner(file)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		parts := strings.Split(text, "\t")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("line %d: expected query, url and grade separated by tabs", line)
+		}
+		grade, err := strconv.ParseFloat(strings.TrimSpace(parts[2]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		query := strings.TrimSpace(parts[0])
+		if judged[query] == nil {
+			judged[query] = map[string]float64{}
+		}
+		judged[query][strings.TrimSpace(parts[1])] = grade
+	}
+	return judged, scanner.Err()
+}
+
+// longDwell is the dwell time from which a click counts as a highly relevant result.
+const longDwell = 30 * time.Second
+
+// addClickJudgments grades clicked results 1, or 2 when read for longDwell, results the judged
+// set already grades for the query keep their grade. It returns the number of results added.
+func addClickJudgments(ir *repository.IndexRepository, judged map[string]map[string]float64) (int, error) {
+	clicks, err := ir.Clicks(time.Time{})
+	if err != nil {
+		return 0, err
+	}
+	graded := map[string]map[string]float64{}
+	for _, c := range clicks {
+		doc, err := ir.GetDocumentByID(c.DocID)
+		if err != nil {
+			continue
+		}
+		if _, ok := judged[c.Query][doc.URL]; ok {
+			continue
+		}
+		grade := 1.0
+		if c.Dwell >= longDwell {
+			grade = 2
+		}
+		if graded[c.Query] == nil {
+			graded[c.Query] = map[string]float64{}
+		}
+		graded[c.Query][doc.URL] = max(graded[c.Query][doc.URL], grade)
+	}
+
+	added := 0
+	for query, grades := range graded {
+		if judged[query] == nil {
+			judged[query] = map[string]float64{}
+		}
+		for url, grade := range grades {
+			judged[query][url] = grade
+			added++
+		}
+	}
+	return added, nil
+}
@@ -3,16 +3,133 @@ package configs
 import (
 	"encoding/json"
 	"os"
+	"time"
 )
 
 type ConfigData struct {
-	BaseURLs       []string `json:"base_urls" validate:"required,len=1:20"`
-	WorkersCount   int      `json:"worker_count" validate:"min=50,max=2000"`
-	TasksCount     int      `json:"task_count" validate:"min=100,max=10000"`
-	MaxLinksInPage int      `json:"max_links_in_page" validate:"min=1,max=100"`
-	MaxDepth       int      `json:"max_depth_crawl" validate:"min=1,max=10"`
-	Rate           int      `json:"rate" validate:"min=1,max=1000"`
-	OnlySameDomain bool     `json:"only_same_domain"`
+	BaseURLs       []string          `json:"base_urls" validate:"required,len=1:20"`
+	WorkersCount   int               `json:"worker_count" validate:"min=50,max=2000"`
+	TasksCount     int               `json:"task_count" validate:"min=100,max=10000"`
+	MaxLinksInPage int               `json:"max_links_in_page" validate:"min=1,max=100"`
+	MaxDepth       int               `json:"max_depth_crawl" validate:"min=1,max=10"`
+	Rate           int               `json:"rate" validate:"min=1,max=1000"`
+	OnlySameDomain bool              `json:"only_same_domain"`
+	Ranking        RankingConfig     `json:"ranking"`
+	Embedding      EmbeddingConfig   `json:"embedding"`
+	VectorIndex    VectorIndexConfig `json:"vector_index"`
+	Analysis       AnalysisConfig    `json:"analysis"`
+	QueryLog       QueryLogConfig    `json:"query_log"`
+}
+
+// QueryLogConfig controls the log of searched queries, entries expire after Retention,
+// 720h by default.
+type QueryLogConfig struct {
+	Disabled  bool   `json:"disabled"`
+	Retention string `json:"retention"`
+}
+
+func (qc QueryLogConfig) RetentionDuration() (time.Duration, error) {
+	if qc.Retention == "" {
+		return 30 * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(qc.Retention)
+}
+
+// AnalysisConfig maps language codes to the analyzer chains documents and queries of that
+// language are analyzed with, languages left out use the default chain. The synonym filter
+// expands queries with the inline Synonyms rules, in Solr syntax, and the rules of SynonymFiles,
+// expanded terms weigh SynonymWeight, 0.5 by default, against 1 for the query's own terms.
+type AnalysisConfig struct {
+	Analyzers     map[string]AnalyzerConfig `json:"analyzers"`
+	Synonyms      []string                  `json:"synonyms"`
+	SynonymFiles  []SynonymFileConfig       `json:"synonym_files"`
+	SynonymWeight float64                   `json:"synonym_weight"`
+}
+
+// SynonymFileConfig is a synonym file, Format is "solr", the default, or "wordnet" for the
+// WordNet prolog database (wn_s.pl).
+type SynonymFileConfig struct {
+	Path   string `json:"path"`
+	Format string `json:"format"`
+}
+
+// AnalyzerConfig names the char filters applied to the raw text, the tokenizer splitting it
+// and the token filters every token passes through, in order.
+type AnalyzerConfig struct {
+	CharFilters []string `json:"char_filters"`
+	Tokenizer   string   `json:"tokenizer"`
+	Filters     []string `json:"filters"`
+}
+
+// VectorIndexConfig tunes the approximate nearest neighbor graph over document embeddings.
+// Higher M and EfConstruction improve recall at the cost of build time and size, EfSearch
+// trades query latency for recall, Candidates is the number of documents retrieved per query.
+type VectorIndexConfig struct {
+	Disabled       bool `json:"disabled"`
+	M              int  `json:"m"`
+	EfConstruction int  `json:"ef_construction"`
+	EfSearch       int  `json:"ef_search"`
+	Candidates     int  `json:"candidates"`
+}
+
+// EmbeddingConfig selects an embedding provider, fields a provider does not use are ignored.
+// Storage is the encoding of stored document embeddings, "float32" or "int8".
+// Concurrent texts are sent together in batches of up to BatchSize, waiting at most BatchWait
+// for a batch to fill, when the provider supports it. Embeddings are cached by content in
+// memory, CacheSize entries, and in the index unless DisableCache is set.
+// Documents are embedded in windows of ChunkWords words sharing ChunkOverlap words.
+type EmbeddingConfig struct {
+	Provider     string `json:"provider"`
+	URL          string `json:"url"`
+	Timeout      string `json:"timeout"`
+	Dimension    int    `json:"dimension"`
+	Model        string `json:"model"`
+	APIKeyEnv    string `json:"api_key_env"`
+	ModelDir     string `json:"model_dir"`
+	Pooling      string `json:"pooling"`
+	Normalize    bool   `json:"normalize"`
+	MaxTokens    int    `json:"max_tokens"`
+	Stride       int    `json:"stride"`
+	Storage      string `json:"storage"`
+	BatchSize    int    `json:"batch_size"`
+	BatchWait    string `json:"batch_wait"`
+	CacheSize    int    `json:"cache_size"`
+	DisableCache bool   `json:"disable_cache"`
+	ChunkWords   int    `json:"chunk_words"`
+	ChunkOverlap int    `json:"chunk_overlap"`
+}
+
+func (ec EmbeddingConfig) TimeoutDuration() (time.Duration, error) {
+	if ec.Timeout == "" {
+		return 15 * time.Second, nil
+	}
+	return time.ParseDuration(ec.Timeout)
+}
+
+func (ec EmbeddingConfig) BatchWaitDuration() (time.Duration, error) {
+	if ec.BatchWait == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(ec.BatchWait)
+}
+
+type RankingConfig struct {
+	Default   string                    `json:"default"`
+	Pipelines map[string]PipelineConfig `json:"pipelines"`
+}
+
+// Mode is "linear" for a weighted sum of signals or "cascade" for comparing
+// weighted signals in order, a negative weight prefers lower values.
+type PipelineConfig struct {
+	Mode    string         `json:"mode"`
+	Signals []SignalConfig `json:"signals"`
+}
+
+// Precision is the number of decimals a cascade compares a signal with, 0 compares exact values.
+type SignalConfig struct {
+	Name      string  `json:"name"`
+	Weight    float64 `json:"weight"`
+	Precision int     `json:"precision"`
 }
 
 func (cfg *ConfigData) Validate() error {
@@ -31,4 +148,4 @@ func UploadLocalConfiguration(fileName string) (*ConfigData, error) {
 	}
 
 	return &cfg, err
-}
\ No newline at end of file
+}
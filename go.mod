@@ -3,8 +3,10 @@ module github.com/box1bs/monocle
 go 1.24.3
 
 require (
+	github.com/blevesearch/snowballstem v0.9.0
 	github.com/dgraph-io/badger/v3 v3.2103.5
 	golang.org/x/net v0.35.0
+	golang.org/x/text v0.22.0
 )
 
 require github.com/stretchr/testify v1.8.4 // indirect
@@ -0,0 +1,81 @@
+package analytics
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/box1bs/monocle/internal/model"
+)
+
+type QueryCount struct {
+	Query 	string
+	Count 	int
+}
+
+// Stats summarizes a query log. Queries are counted by their lower cased text,
+// latency percentiles are nearest rank.
+type Stats struct {
+	Queries 	int
+	Distinct 	int
+	ZeroResults int
+	Corrected 	int
+	Top 		[]QueryCount
+	TopZero 	[]QueryCount
+	P50 		time.Duration
+	P90 		time.Duration
+	P99 		time.Duration
+	Max 		time.Duration
+}
+
+// Summarize aggregates entries, Top and TopZero keep the n most frequent queries
+// and the n most frequent queries that found nothing.
+func Summarize(entries []model.QueryLogEntry, n int) Stats {
+	st := Stats{Queries: len(entries)}
+	counts, zero := map[string]int{}, map[string]int{}
+	latencies := make([]time.Duration, 0, len(entries))
+	for _, e := range entries {
+		query := strings.Join(strings.Fields(strings.ToLower(e.Query)), " ")
+		counts[query]++
+		if e.Results == 0 {
+			st.ZeroResults++
+			zero[query]++
+		}
+		if e.Corrected != "" {
+			st.Corrected++
+		}
+		latencies = append(latencies, e.Latency)
+	}
+	st.Distinct = len(counts)
+	st.Top, st.TopZero = top(counts, n), top(zero, n)
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	st.P50, st.P90, st.P99 = percentile(latencies, 50), percentile(latencies, 90), percentile(latencies, 99)
+	if len(latencies) > 0 {
+		st.Max = latencies[len(latencies) - 1]
+	}
+	return st
+}
+
+func top(counts map[string]int, n int) []QueryCount {
+	out := make([]QueryCount, 0, len(counts))
+	for query, count := range counts {
+		out = append(out, QueryCount{Query: query, Count: count})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Query < out[j].Query
+	})
+	return out[:min(n, len(out))]
+}
+
+// percentile expects sorted values.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p * len(sorted) + 99) / 100
+	return sorted[max(rank, 1) - 1]
+}
@@ -0,0 +1,126 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// batchProvider is implemented by providers able to embed several texts in one request,
+// the result holds the chunk vectors of every text in input order.
+type batchProvider interface {
+	Provider
+	VectorizeBatch([]string, context.Context) ([][][]float64, error)
+}
+
+const defaultBatchWait = 10 * time.Millisecond
+
+type batchResult struct {
+	vecs 	[][]float64
+	err 	error
+}
+
+type batchRequest struct {
+	text 	string
+	ctx 	context.Context
+	done 	chan batchResult
+}
+
+// batcher coalesces concurrent Vectorize calls into a single multi text request, a batch is
+// sent once it is full or wait has passed since its first text arrived.
+type batcher struct {
+	p 			batchProvider
+	size 		int
+	wait 		time.Duration
+	mu 			sync.Mutex
+	pending 	[]*batchRequest
+	generation 	int
+}
+
+func newBatcher(p batchProvider, size int, wait time.Duration) *batcher {
+	if wait <= 0 {
+		wait = defaultBatchWait
+	}
+	return &batcher{p: p, size: size, wait: wait}
+}
+
+func (b *batcher) Dimension() int {
+	return b.p.Dimension()
+}
+
+func (b *batcher) Vectorize(text string, ctx context.Context) ([][]float64, error) {
+	r := &batchRequest{text: text, ctx: ctx, done: make(chan batchResult, 1)}
+
+	b.mu.Lock()
+	b.pending = append(b.pending, r)
+	switch {
+	case len(b.pending) >= b.size:
+		b.flushLocked()
+	case len(b.pending) == 1:
+		gen := b.generation
+		time.AfterFunc(b.wait, func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			// the batch this timer was started for may have been sent already
+			if gen == b.generation {
+				b.flushLocked()
+			}
+		})
+	}
+	b.mu.Unlock()
+
+	select {
+	case res := <-r.done:
+		return res.vecs, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (b *batcher) flushLocked() {
+	batch := b.pending
+	b.pending = nil
+	b.generation++
+	if len(batch) > 0 {
+		go b.send(batch)
+	}
+}
+
+func (b *batcher) send(batch []*batchRequest) {
+	// the request is abandoned only when every caller gave up
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	remaining := int32(len(batch))
+	for _, r := range batch {
+		stop := context.AfterFunc(r.ctx, func() {
+			if atomic.AddInt32(&remaining, -1) == 0 {
+				cancel()
+			}
+		})
+		defer stop()
+	}
+
+	// identical texts of concurrent callers are embedded once
+	texts := make([]string, 0, len(batch))
+	index := make(map[string]int, len(batch))
+	for _, r := range batch {
+		if _, ok := index[r.text]; !ok {
+			index[r.text] = len(texts)
+			texts = append(texts, r.text)
+		}
+	}
+
+	vecs, err := b.p.VectorizeBatch(texts, ctx)
+	if err == nil && len(vecs) != len(texts) {
+		err = fmt.Errorf("batch of %d texts returned %d results", len(texts), len(vecs))
+	}
+	for _, r := range batch {
+		if err != nil {
+			r.done <- batchResult{err: err}
+			continue
+		}
+		r.done <- batchResult{vecs: vecs[index[r.text]]}
+	}
+}
@@ -0,0 +1,74 @@
+package embedding
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/box1bs/monocle/configs"
+	"github.com/box1bs/monocle/pkg/lru"
+)
+
+const defaultCacheSize = 4096
+
+// Store persists embeddings by content key, GetCachedEmbedding returns nil for unknown keys.
+type Store interface {
+	GetCachedEmbedding([32]byte) ([][]float64, error)
+	CacheEmbedding([32]byte, [][]float64) error
+}
+
+// cachedProvider answers repeated texts from memory or the store instead of the provider.
+// Keys hash the provider configuration together with the text, every option that changes the
+// vectors included, so switching models or normalization never returns stale vectors.
+type cachedProvider struct {
+	p 			Provider
+	memory 		*lru.Cache[[32]byte, [][]float64]
+	store 		Store
+	fingerprint string
+}
+
+// NewCache wraps p with a memory cache of cfg.CacheSize entries and the optional store.
+// A disabled cache returns p unchanged.
+func NewCache(p Provider, cfg configs.EmbeddingConfig, store Store) Provider {
+	if cfg.DisableCache {
+		return p
+	}
+	size := cfg.CacheSize
+	if size <= 0 {
+		size = defaultCacheSize
+	}
+	return &cachedProvider{
+		p: 				p,
+		memory: 		lru.New[[32]byte, [][]float64](size),
+		store: 			store,
+		fingerprint: 	fmt.Sprintf("%s|%s|%s|%s|%s|%t|%d|%d|%d", cfg.Provider, cfg.URL, cfg.Model, cfg.ModelDir, cfg.Pooling, cfg.Normalize, cfg.MaxTokens, cfg.Stride, p.Dimension()),
+	}
+}
+
+func (c *cachedProvider) Dimension() int {
+	return c.p.Dimension()
+}
+
+func (c *cachedProvider) Vectorize(text string, ctx context.Context) ([][]float64, error) {
+	key := sha256.Sum256([]byte(c.fingerprint + "\x00" + text))
+	if vecs, ok := c.memory.Get(key); ok {
+		return vecs, nil
+	}
+	if c.store != nil {
+		if vecs, err := c.store.GetCachedEmbedding(key); err == nil && vecs != nil {
+			c.memory.Add(key, vecs)
+			return vecs, nil
+		}
+	}
+
+	vecs, err := c.p.Vectorize(text, ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.memory.Add(key, vecs)
+	if c.store != nil {
+		// the cache is best effort, a failed write only costs a later recomputation
+		c.store.CacheEmbedding(key, vecs)
+	}
+	return vecs, nil
+}
@@ -0,0 +1,79 @@
+package embedding
+
+import (
+	"context"
+	"testing"
+
+	"github.com/box1bs/monocle/configs"
+)
+
+// countingProvider returns the number of calls so far as its only vector value.
+type countingProvider struct {
+	calls int
+}
+
+func (p *countingProvider) Vectorize(string, context.Context) ([][]float64, error) {
+	p.calls++
+	return [][]float64{{float64(p.calls)}}, nil
+}
+
+func (p *countingProvider) Dimension() int {
+	return 1
+}
+
+type mapStore map[[32]byte][][]float64
+
+func (s mapStore) GetCachedEmbedding(key [32]byte) ([][]float64, error) {
+	return s[key], nil
+}
+
+func (s mapStore) CacheEmbedding(key [32]byte, vecs [][]float64) error {
+	s[key] = vecs
+	return nil
+}
+
+func TestCacheAnswersRepeatedTexts(t *testing.T) {
+	p, store := &countingProvider{}, mapStore{}
+	cfg := configs.EmbeddingConfig{Provider: "local", ModelDir: "model"}
+	c := NewCache(p, cfg, store)
+	for range 3 {
+		if _, err := c.Vectorize("text", context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if p.calls != 1 {
+		t.Fatalf("provider called %d times, want 1", p.calls)
+	}
+	// a new cache over the same store finds the text without the provider
+	if _, err := NewCache(p, cfg, store).Vectorize("text", context.Background()); err != nil || p.calls != 1 {
+		t.Fatalf("provider called %d times after reopening the store, want 1 (err %v)", p.calls, err)
+	}
+}
+
+func TestCacheKeysOnVectorOptions(t *testing.T) {
+	base := configs.EmbeddingConfig{Provider: "local", ModelDir: "model", Pooling: "mean"}
+	changes := map[string]func(*configs.EmbeddingConfig){
+		"normalize": 	func(c *configs.EmbeddingConfig) { c.Normalize = true },
+		"pooling": 		func(c *configs.EmbeddingConfig) { c.Pooling = "cls" },
+		"model dir": 	func(c *configs.EmbeddingConfig) { c.ModelDir = "other" },
+		"max tokens": 	func(c *configs.EmbeddingConfig) { c.MaxTokens = 128 },
+		"stride": 		func(c *configs.EmbeddingConfig) { c.Stride = 64 },
+	}
+	for name, change := range changes {
+		t.Run(name, func(t *testing.T) {
+			p, store := &countingProvider{}, mapStore{}
+			if _, err := NewCache(p, base, store).Vectorize("text", context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			cfg := base
+			change(&cfg)
+			vecs, err := NewCache(p, cfg, store).Vectorize("text", context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.calls != 2 || vecs[0][0] != 2 {
+				t.Fatalf("changed %s returned the cached vector", name)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package embedding
+
+import (
+	"unicode"
+
+	"github.com/box1bs/monocle/internal/model"
+)
+
+const (
+	defaultChunkWords = 200
+	defaultChunkOverlap = 40
+)
+
+// SplitChunks cuts text into windows of size words, consecutive windows share overlap words.
+// Word counts stay well below the token limits of the models, so every chunk gets one vector.
+func SplitChunks(text string, size, overlap int) []model.Chunk {
+	if size <= 0 {
+		size = defaultChunkWords
+	}
+	if overlap < 0 || overlap >= size {
+		overlap = min(defaultChunkOverlap, size / 2)
+	}
+
+	words := []model.Chunk{}
+	start := -1
+	for i, r := range text {
+		if unicode.IsSpace(r) {
+			if start >= 0 {
+				words = append(words, model.Chunk{Start: start, End: i})
+				start = -1
+			}
+		} else if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		words = append(words, model.Chunk{Start: start, End: len(text)})
+	}
+
+	chunks := []model.Chunk{}
+	for i := 0; i < len(words); i += size - overlap {
+		last := min(i + size, len(words)) - 1
+		chunks = append(chunks, model.Chunk{Start: words[i].Start, End: words[last].End})
+		if last == len(words) - 1 {
+			break
+		}
+	}
+	return chunks
+}
@@ -0,0 +1,121 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/box1bs/monocle/configs"
+)
+
+const defaultFlaskURL = "http://127.0.0.1:50920/vectorize"
+
+// FlaskServiceVersion is the EMBEDDING_VERSION of semantic_embeddings/app.py the provider expects,
+// both change together whenever the service embeds differently. Services from before the version
+// was sent answer without one.
+const FlaskServiceVersion = 2
+
+type flaskProvider struct {
+	client 		*http.Client
+	url 		string
+	batchURL 	string
+	dim 		int
+}
+
+type VecResponce struct {
+	Vec 	[][]float64 	`json:"vec"`
+	Version int 			`json:"version"`
+}
+
+type batchVecResponce struct {
+	Vecs 	[][][]float64 	`json:"vecs"`
+	Version int 			`json:"version"`
+}
+
+func checkServiceVersion(version int) error {
+	if version != FlaskServiceVersion {
+		return fmt.Errorf("embedding service sends version %d vectors, expected version %d: run semantic_embeddings/app.py of this tree", version, FlaskServiceVersion)
+	}
+	return nil
+}
+
+func newFlaskProvider(cfg configs.EmbeddingConfig) (Provider, error) {
+	if cfg.Dimension <= 0 {
+		return nil, errors.New("flask provider requires a positive dimension")
+	}
+	timeout, err := cfg.TimeoutDuration()
+	if err != nil {
+		return nil, err
+	}
+	url := cfg.URL
+	if url == "" {
+		url = defaultFlaskURL
+	}
+	return &flaskProvider{
+		client: 	&http.Client{Timeout: timeout},
+		url: 		url,
+		batchURL: 	strings.TrimSuffix(url, "/") + "_batch",
+		dim: 		cfg.Dimension,
+	}, nil
+}
+
+func (v *flaskProvider) Dimension() int {
+	return v.dim
+}
+
+func (v *flaskProvider) Vectorize(text string, ctx context.Context) ([][]float64, error) {
+	var vecResponce VecResponce
+	if err := v.post(ctx, v.url, map[string]any{"text": text}, &vecResponce); err != nil {
+		return nil, err
+	}
+	if err := checkServiceVersion(vecResponce.Version); err != nil {
+		return nil, err
+	}
+	return vecResponce.Vec, checkDimension(vecResponce.Vec, v.dim)
+}
+
+// VectorizeBatch posts all texts to the batch endpoint next to the single text one.
+func (v *flaskProvider) VectorizeBatch(texts []string, ctx context.Context) ([][][]float64, error) {
+	var batch batchVecResponce
+	if err := v.post(ctx, v.batchURL, map[string]any{"texts": texts}, &batch); err != nil {
+		return nil, err
+	}
+	if err := checkServiceVersion(batch.Version); err != nil {
+		return nil, err
+	}
+	for _, vecs := range batch.Vecs {
+		if err := checkDimension(vecs, v.dim); err != nil {
+			return nil, err
+		}
+	}
+	return batch.Vecs, nil
+}
+
+func (v *flaskProvider) post(ctx context.Context, url string, payload any, out any) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(payload); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+        return fmt.Errorf("unexpected status: %v", resp.Status)
+    }
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
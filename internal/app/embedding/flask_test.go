@@ -0,0 +1,59 @@
+package embedding
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/box1bs/monocle/configs"
+)
+
+func TestFlaskChecksServiceVersion(t *testing.T) {
+	tests := []struct {
+		name 	string
+		body 	string
+		wantErr string
+	}{
+		{"current", `{"vec": [[1, 0]], "version": 2}`, ""},
+		{"unversioned", `{"vec": [[1, 0]]}`, "version 0"},
+		{"newer", `{"vec": [[1, 0]], "version": 3}`, "version 3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+			p, err := New(configs.EmbeddingConfig{Provider: "flask", URL: srv.URL + "/vectorize", Dimension: 2})
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = p.Vectorize("text", context.Background())
+			if tt.wantErr == "" && err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("err = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVersion(t *testing.T) {
+	if got := Version(configs.EmbeddingConfig{}); got != Version(configs.EmbeddingConfig{Provider: "flask", URL: "http://other"}) {
+		t.Fatalf("flask versions differ by url: %q", got)
+	}
+	local := configs.EmbeddingConfig{Provider: "local", ModelDir: "models/minilm", Pooling: "mean"}
+	moved := local
+	moved.ModelDir = "/srv/minilm"
+	if Version(local) != Version(moved) {
+		t.Fatal("moving the model directory changed the version")
+	}
+	cls := local
+	cls.Pooling = "cls"
+	if Version(local) == Version(cls) {
+		t.Fatal("pooling does not change the version")
+	}
+}
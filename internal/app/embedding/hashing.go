@@ -0,0 +1,62 @@
+package embedding
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/box1bs/monocle/configs"
+)
+
+const defaultHashingDimension = 256
+
+// hashingProvider is a deterministic bag of words embedder for tests and offline runs,
+// every lowercased word is hashed into a signed bucket and the vector is L2 normalized.
+type hashingProvider struct {
+	dim int
+}
+
+func newHashingProvider(cfg configs.EmbeddingConfig) (Provider, error) {
+	dim := cfg.Dimension
+	if dim <= 0 {
+		dim = defaultHashingDimension
+	}
+	return &hashingProvider{dim: dim}, nil
+}
+
+func (p *hashingProvider) Dimension() int {
+	return p.dim
+}
+
+func (p *hashingProvider) Vectorize(text string, ctx context.Context) ([][]float64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	vec := make([]float64, p.dim)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		h := fnv.New64a()
+		h.Write([]byte(word))
+		sum := h.Sum64()
+		sign := 1.0
+		if sum >> 63 == 1 {
+			sign = -1.0
+		}
+		vec[sum % uint64(p.dim)] += sign
+	}
+
+	norm := 0.0
+	for _, v := range vec {
+		norm += v * v
+	}
+	if norm = math.Sqrt(norm); norm > 0 {
+		for i := range vec {
+			vec[i] /= norm
+		}
+	}
+	return [][]float64{vec}, nil
+}
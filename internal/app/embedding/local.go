@@ -0,0 +1,46 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+
+	"github.com/box1bs/monocle/configs"
+	"github.com/box1bs/monocle/pkg/bert"
+)
+
+// localProvider runs the sentence transformer in process, it expects config.json,
+// vocab.txt and model.safetensors in the model directory.
+type localProvider struct {
+	model *bert.Model
+}
+
+func newLocalProvider(cfg configs.EmbeddingConfig) (Provider, error) {
+	if cfg.ModelDir == "" {
+		return nil, errors.New("local provider requires model_dir")
+	}
+	maxTokens := cfg.MaxTokens
+	if maxTokens == 0 {
+		maxTokens = 512
+	}
+	m, err := bert.Load(cfg.ModelDir, bert.Options{
+		Pooling: 	cfg.Pooling,
+		Normalize: 	cfg.Normalize,
+		MaxTokens: 	maxTokens,
+		Stride: 	cfg.Stride,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Dimension > 0 && cfg.Dimension != m.Dimension() {
+		return nil, errors.New("configured dimension does not match the model hidden size")
+	}
+	return &localProvider{model: m}, nil
+}
+
+func (p *localProvider) Dimension() int {
+	return p.model.Dimension()
+}
+
+func (p *localProvider) Vectorize(text string, ctx context.Context) ([][]float64, error) {
+	return p.model.Embed(ctx, text)
+}
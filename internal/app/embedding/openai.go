@@ -0,0 +1,131 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/box1bs/monocle/configs"
+)
+
+// openAIProvider talks to any OpenAI compatible /v1/embeddings endpoint.
+type openAIProvider struct {
+	client 	*http.Client
+	url 	string
+	model 	string
+	apiKey 	string
+	dim 	int
+}
+
+type embeddingsRequest struct {
+	Model 		string 	`json:"model"`
+	Input 		any 	`json:"input"`
+	Dimensions 	int 	`json:"dimensions,omitempty"`
+}
+
+type embeddingsResponse struct {
+	Data []struct {
+		Index 		int 		`json:"index"`
+		Embedding 	[]float64 	`json:"embedding"`
+	} `json:"data"`
+}
+
+func newOpenAIProvider(cfg configs.EmbeddingConfig) (Provider, error) {
+	if cfg.Dimension <= 0 {
+		return nil, errors.New("openai provider requires a positive dimension")
+	}
+	if cfg.Model == "" {
+		return nil, errors.New("openai provider requires a model")
+	}
+	timeout, err := cfg.TimeoutDuration()
+	if err != nil {
+		return nil, err
+	}
+	base := cfg.URL
+	if base == "" {
+		base = "https://api.openai.com"
+	}
+	keyEnv := cfg.APIKeyEnv
+	if keyEnv == "" {
+		keyEnv = "OPENAI_API_KEY"
+	}
+	return &openAIProvider{
+		client: &http.Client{Timeout: timeout},
+		url: 	strings.TrimSuffix(base, "/") + "/v1/embeddings",
+		model: 	cfg.Model,
+		apiKey: os.Getenv(keyEnv),
+		dim: 	cfg.Dimension,
+	}, nil
+}
+
+func (p *openAIProvider) Dimension() int {
+	return p.dim
+}
+
+func (p *openAIProvider) Vectorize(text string, ctx context.Context) ([][]float64, error) {
+	vecs, err := p.embed(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	return vecs, checkDimension(vecs, p.dim)
+}
+
+// VectorizeBatch sends all texts as one input list, every text gets a single vector.
+func (p *openAIProvider) VectorizeBatch(texts []string, ctx context.Context) ([][][]float64, error) {
+	vecs, err := p.embed(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDimension(vecs, p.dim); err != nil {
+		return nil, err
+	}
+	out := make([][][]float64, len(vecs))
+	for i, v := range vecs {
+		out[i] = [][]float64{v}
+	}
+	return out, nil
+}
+
+func (p *openAIProvider) embed(ctx context.Context, input any) ([][]float64, error) {
+	body, err := json.Marshal(embeddingsRequest{Model: p.model, Input: input})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer " + p.apiKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %v", resp.Status)
+	}
+
+	var er embeddingsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&er); err != nil {
+		return nil, err
+	}
+	vecs := make([][]float64, len(er.Data))
+	for _, d := range er.Data {
+		if d.Index < 0 || d.Index >= len(vecs) {
+			return nil, fmt.Errorf("embedding index %d out of range", d.Index)
+		}
+		vecs[d.Index] = d.Embedding
+	}
+	return vecs, nil
+}
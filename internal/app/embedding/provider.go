@@ -0,0 +1,101 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/box1bs/monocle/configs"
+)
+
+// Provider turns text into one vector per chunk, every vector has Dimension values.
+type Provider interface {
+	Vectorize(string, context.Context) ([][]float64, error)
+	Dimension() int
+}
+
+type factory func(configs.EmbeddingConfig) (Provider, error)
+
+var (
+	registryMu 	sync.RWMutex
+	registry 	= map[string]factory{}
+)
+
+func Register(name string, f factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[name] = f
+}
+
+// New builds the configured provider, providers able to embed several texts at once
+// get their concurrent calls batched when cfg.BatchSize is above one.
+func New(cfg configs.EmbeddingConfig) (Provider, error) {
+	name := cfg.Provider
+	if name == "" {
+		name = "flask"
+	}
+	registryMu.RLock()
+	f, ok := registry[name]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown embedding provider %q, available: %s", name, strings.Join(Providers(), ", "))
+	}
+	p, err := f(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	bp, ok := p.(batchProvider)
+	if !ok || cfg.BatchSize <= 1 {
+		return p, nil
+	}
+	wait, err := cfg.BatchWaitDuration()
+	if err != nil {
+		return nil, err
+	}
+	return newBatcher(bp, cfg.BatchSize, wait), nil
+}
+
+// Version names what produces the vectors of cfg, an index only compares vectors of one version.
+// Flask vectors are those of the service version, other providers are named by their configuration.
+func Version(cfg configs.EmbeddingConfig) string {
+	name := cfg.Provider
+	if name == "" {
+		name = "flask"
+	}
+	if name == "flask" {
+		return fmt.Sprintf("flask/%d", FlaskServiceVersion)
+	}
+	return fmt.Sprintf("%s/%s/%s/%s/%t/%d/%d", name, cfg.Model, filepath.Base(cfg.ModelDir), cfg.Pooling, cfg.Normalize, cfg.MaxTokens, cfg.Stride)
+}
+
+func Providers() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	Register("flask", newFlaskProvider)
+	Register("openai", newOpenAIProvider)
+	Register("hashing", newHashingProvider)
+	Register("local", newLocalProvider)
+}
+
+// checkDimension rejects vectors a provider returned with a different size than it declared.
+func checkDimension(vecs [][]float64, dim int) error {
+	for _, v := range vecs {
+		if len(v) != dim {
+			return fmt.Errorf("embedding dimension mismatch: got %d, expected %d", len(v), dim)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package evaluation
+
+import (
+	"math"
+	"sort"
+)
+
+type Metrics struct {
+	NDCG 		float64
+	AP 			float64
+	RR 			float64
+	Precision 	float64
+	Recall 		float64
+}
+
+// Evaluate scores one ranked list of document ids against the judgments of its query,
+// nDCG, precision and recall are cut at k while AP and RR use the whole list.
+func Evaluate(ranked []string, judged map[string]int, k int) Metrics {
+	var m Metrics
+	relevant := 0
+	for _, rel := range judged {
+		if rel > 0 {
+			relevant++
+		}
+	}
+	if relevant == 0 {
+		return m
+	}
+
+	found := 0
+	dcg := 0.0
+	for i, id := range ranked {
+		rel := judged[id]
+		if i < k {
+			dcg += gain(rel, i)
+		}
+		if rel <= 0 {
+			continue
+		}
+		found++
+		m.AP += float64(found) / float64(i + 1)
+		if m.RR == 0 {
+			m.RR = 1.0 / float64(i + 1)
+		}
+		if i < k {
+			m.Precision++
+			m.Recall++
+		}
+	}
+	m.AP /= float64(relevant)
+	m.Precision /= float64(k)
+	m.Recall /= float64(relevant)
+
+	if idcg := idealDCG(judged, k); idcg > 0 {
+		m.NDCG = dcg / idcg
+	}
+	return m
+}
+
+func gain(rel, i int) float64 {
+	if rel <= 0 {
+		return 0
+	}
+	return (math.Pow(2, float64(rel)) - 1) / math.Log2(float64(i + 2))
+}
+
+func idealDCG(judged map[string]int, k int) float64 {
+	grades := make([]int, 0, len(judged))
+	for _, rel := range judged {
+		if rel > 0 {
+			grades = append(grades, rel)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(grades)))
+	idcg := 0.0
+	for i := 0; i < len(grades) && i < k; i++ {
+		idcg += gain(grades[i], i)
+	}
+	return idcg
+}
+
+// Mean averages per query metrics.
+func Mean(all []Metrics) Metrics {
+	var m Metrics
+	if len(all) == 0 {
+		return m
+	}
+	for _, q := range all {
+		m.NDCG += q.NDCG
+		m.AP += q.AP
+		m.RR += q.RR
+		m.Precision += q.Precision
+		m.Recall += q.Recall
+	}
+	n := float64(len(all))
+	m.NDCG /= n
+	m.AP /= n
+	m.RR /= n
+	m.Precision /= n
+	m.Recall /= n
+	return m
+}
@@ -0,0 +1,105 @@
+package evaluation
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name 	string
+		ranked 	[]string
+		judged 	map[string]int
+		k 		int
+		want 	Metrics
+	}{
+		{
+			// relevant at ranks 1 and 3 out of 3 judged relevant, e is never retrieved.
+			// DCG = (2^1-1)/log2(2) + (2^2-1)/log2(4) = 1 + 1.5, the ideal order 2, 1, 1 gives
+			// 3/log2(2) + 1/log2(3) + 1/log2(4). AP = (1/1 + 2/3) / 3.
+			name: "graded",
+			ranked: []string{"a", "b", "c", "d"},
+			judged: map[string]int{"a": 1, "c": 2, "e": 1},
+			k: 3,
+			want: Metrics{
+				NDCG: 2.5 / (3 + 1 / math.Log2(3) + 0.5),
+				AP: 5.0 / 9,
+				RR: 1,
+				Precision: 2.0 / 3,
+				Recall: 2.0 / 3,
+			},
+		},
+		{
+			name: "perfect",
+			ranked: []string{"a", "b"},
+			judged: map[string]int{"a": 2, "b": 1},
+			k: 2,
+			want: Metrics{NDCG: 1, AP: 1, RR: 1, Precision: 1, Recall: 1},
+		},
+		{
+			// the only relevant document is at rank 2, past the cutoff
+			name: "below cutoff",
+			ranked: []string{"b", "a"},
+			judged: map[string]int{"a": 1},
+			k: 1,
+			want: Metrics{AP: 0.5, RR: 0.5},
+		},
+		{
+			// precision divides by k even when fewer documents are retrieved
+			name: "short list",
+			ranked: []string{"a"},
+			judged: map[string]int{"a": 1, "b": 1},
+			k: 4,
+			want: Metrics{
+				NDCG: 1 / (1 + 1 / math.Log2(3)),
+				AP: 0.5,
+				RR: 1,
+				Precision: 0.25,
+				Recall: 0.5,
+			},
+		},
+		{
+			name: "nothing relevant retrieved",
+			ranked: []string{"x", "y"},
+			judged: map[string]int{"a": 1, "x": 0},
+			k: 2,
+		},
+		{
+			name: "no relevant judgments",
+			ranked: []string{"a"},
+			judged: map[string]int{"a": 0},
+			k: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Evaluate(tt.ranked, tt.judged, tt.k)
+			if !near(got, tt.want) {
+				t.Fatalf("Evaluate = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMean(t *testing.T) {
+	got := Mean([]Metrics{
+		{NDCG: 1, AP: 0.5, RR: 1, Precision: 0.2, Recall: 1},
+		{NDCG: 0, AP: 0.25, RR: 0.5, Precision: 0, Recall: 0.5},
+	})
+	want := Metrics{NDCG: 0.5, AP: 0.375, RR: 0.75, Precision: 0.1, Recall: 0.75}
+	if !near(got, want) {
+		t.Fatalf("Mean = %+v, want %+v", got, want)
+	}
+	if got := Mean(nil); got != (Metrics{}) {
+		t.Fatalf("Mean(nil) = %+v", got)
+	}
+}
+
+func near(a, b Metrics) bool {
+	for _, d := range []float64{a.NDCG - b.NDCG, a.AP - b.AP, a.RR - b.RR, a.Precision - b.Precision, a.Recall - b.Recall} {
+		if math.Abs(d) > 1e-9 {
+			return false
+		}
+	}
+	return true
+}
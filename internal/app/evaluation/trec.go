@@ -0,0 +1,77 @@
+package evaluation
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Qrels maps query id to document id (the document URL) to graded relevance.
+type Qrels map[string]map[string]int
+
+type Query struct {
+	ID 		string
+	Text 	string
+}
+
+// LoadQrels reads a TREC qrels file: "qid iteration docid relevance" per line.
+func LoadQrels(path string) (Qrels, error) {
+	qrels := Qrels{}
+	err := scanLines(path, func(line int, text string) error {
+		fields := strings.Fields(text)
+		if len(fields) != 4 {
+			return fmt.Errorf("line %d: expected 4 fields, got %d", line, len(fields))
+		}
+		rel, err := strconv.Atoi(fields[3])
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if qrels[fields[0]] == nil {
+			qrels[fields[0]] = map[string]int{}
+		}
+		qrels[fields[0]][fields[2]] = rel
+		return nil
+	})
+	return qrels, err
+}
+
+// LoadQueries reads "qid query text" per line, the id is separated by a tab or the first space.
+func LoadQueries(path string) ([]Query, error) {
+	queries := []Query{}
+	err := scanLines(path, func(line int, text string) error {
+		id, query, ok := strings.Cut(text, "\t")
+		if !ok {
+			id, query, ok = strings.Cut(text, " ")
+		}
+		if !ok || strings.TrimSpace(query) == "" {
+			return fmt.Errorf("line %d: expected query id and text", line)
+		}
+		queries = append(queries, Query{ID: strings.TrimSpace(id), Text: strings.TrimSpace(query)})
+		return nil
+	})
+	return queries, err
+}
+
+func scanLines(path string, handle func(int, string) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if err := handle(line, text); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,62 @@
+package indexer
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/box1bs/monocle/internal/app/embedding"
+	"github.com/box1bs/monocle/internal/model"
+)
+
+// embedWorkers bounds the chunks of one document vectorized at the same time, providers
+// without batching get one request per chunk.
+const embedWorkers = 8
+
+// SetChunking sets the window and overlap in words documents are embedded with, zero values use the defaults.
+func (idx *indexer) SetChunking(words, overlap int) {
+	idx.chunkWords, idx.chunkOverlap = words, overlap
+}
+
+// EmbedDocument joins the passages into the document text and embeds it chunk by chunk,
+// up to embedWorkers chunks are vectorized concurrently so that a batching provider sends them together.
+func (idx *indexer) EmbedDocument(c context.Context, doc *model.Document, passages []model.Passage) error {
+	texts := make([]string, 0, len(passages))
+	for _, passage := range passages {
+		if text := strings.TrimSpace(passage.Text); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	doc.Text = strings.Join(texts, "\n")
+	spans := embedding.SplitChunks(doc.Text, idx.chunkWords, idx.chunkOverlap)
+
+	vecs := make([][][]float64, len(spans))
+	errs := make([]error, len(spans))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, embedWorkers)
+	for i, span := range spans {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			vecs[i], errs[i] = idx.vectorizer.Vectorize(doc.Text[span.Start:span.End], c)
+		}()
+	}
+	wg.Wait()
+
+	doc.WordVec, doc.Chunks = nil, nil
+	for i, span := range spans {
+		if errs[i] != nil {
+			return errs[i]
+		}
+		// a provider may still split a long chunk, every vector points at the whole chunk then
+		for _, v := range vecs[i] {
+			doc.WordVec = append(doc.WordVec, v)
+			doc.Chunks = append(doc.Chunks, span)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package indexer
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/box1bs/monocle/internal/model"
+)
+
+// Highlight returns the byte ranges of the words in text whose stems match a query term,
+// text is stemmed as lang.
+func (idx *indexer) Highlight(text, lang string, terms []model.QueryTerm) [][2]int {
+	analyzer := idx.analyzers.Get(lang)
+	wanted := map[string]struct{}{}
+	for _, term := range terms {
+		if term.Term != "" {
+			wanted[term.Term] = struct{}{}
+		}
+	}
+	if len(wanted) == 0 {
+		return nil
+	}
+
+	stems := map[string]bool{}
+	matches := func(word string) bool {
+		word = strings.ToLower(word)
+		if ok, seen := stems[word]; seen {
+			return ok
+		}
+		ok := false
+		for _, stemmed := range analyzer.Terms(word) {
+			if _, ok = wanted[stemmed]; ok {
+				break
+			}
+		}
+		stems[word] = ok
+		return ok
+	}
+
+	ranges := [][2]int{}
+	start := -1
+	for i, r := range text + " " {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 && matches(text[start:i]) {
+			ranges = append(ranges, [2]int{start, i})
+		}
+		start = -1
+	}
+	return ranges
+}
@@ -5,9 +5,15 @@ import (
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 
 	"github.com/box1bs/monocle/configs"
+	"github.com/box1bs/monocle/internal/app/embedding"
 	"github.com/box1bs/monocle/internal/app/indexer/spellChecker"
 	"github.com/box1bs/monocle/internal/app/indexer/textHandling"
 	"github.com/box1bs/monocle/internal/app/scraper"
@@ -18,10 +24,14 @@ import (
 type repository interface {
 	LoadVisitedUrls(*sync.Map) error
 	SaveVisitedUrls(*sync.Map) error
-	IndexDocumentWords(context.Context, [32]byte, []int, map[string][]model.Position) error
+	IndexDocument(context.Context, *model.Document, []int, map[int][]model.Position) error
 	GetDocumentsByWord(int) (map[[32]byte]*model.WordCountAndPositions, error)
-	IndexNGrams(string, ...string) error
+	IndexNGrams(map[string][]string) error
 	GetWordsByNGrams(...string) ([]string, error)
+	SetSpellingWords([32]byte, ...string) ([]string, []string, error)
+	LoadSpellingDictionary(func(string, int)) error
+	CountQuery(string) error
+	LoadQueryCounts(func(string, int)) error
 	
 	SavePageRank(map[string]int) error
 	LoadPageRank() (map[string]int, error)
@@ -30,32 +40,43 @@ type repository interface {
 	GetDocumentByID([32]byte) (*model.Document, error)
 	GetAllDocuments() ([]*model.Document, error)
 	GetDocumentsCount() (int, error)
+	GetCorpusStats(...int) (*model.CorpusStats, error)
+	DeleteDocument([32]byte) error
+	GetEmbeddings([32]byte) ([][]float64, error)
+	GetChunks([32]byte) (string, []model.Chunk, error)
+	EnsureEmbeddingDimension(int) error
+	EnsureStemmerVersion(int) error
+	EnsureEmbeddingVersion(string) error
+
+	SaveVectorNodes([]byte, map[uint64][]byte) error
+	LoadVectorIndex(func(uint64, []byte) error) ([]byte, error)
+	ClearVectorIndex() error
 
 	CheckContent([32]byte, [32]byte) (bool, *model.Document, error)
 	
 	TransferToSequence(...string) ([]int, error)
 	SaveToSequence(...string) ([]int, error)
+	Generation() uint64
 }
 
 type logger interface {
 	Write(string)
 }
 
-type vectorizer interface {
-	Vectorize(string, context.Context) ([][]float64, error)
-}
-
 type indexer struct {
-	stemmer 	*textHandling.EnglishStemmer
+	analyzers 	*textHandling.Analyzers
 	sc 			*spellChecker.SpellChecker
 	repository 	repository
-	vectorizer 	vectorizer
+	vectorizer 	embedding.Provider
+	vectors 	*vectorIndex
 	logger 		logger
+	chunkWords 	int
+	chunkOverlap 	int
 }
 
-func NewIndexer(repo repository, vec vectorizer, logger logger, maxTypo, nGramCount int) *indexer {
+func NewIndexer(repo repository, vec embedding.Provider, logger logger, maxTypo, nGramCount int) *indexer {
 	return &indexer{
-		stemmer:   	textHandling.NewEnglishStemmer(),
+		analyzers: 	textHandling.DefaultAnalyzers(),
 		sc:        	spellChecker.NewSpellChecker(maxTypo, nGramCount),
 		repository: repo,
 		vectorizer: vec,
@@ -84,93 +105,292 @@ func (idx *indexer) Index(config *configs.ConfigData, global context.Context) {
 		OnlySameDomain: config.OnlySameDomain,
 		Rate:			config.Rate,
 		DocNGramCount: 	64,
-	}, wp, idx, global, pr, idx.logger.Write, idx.vectorizer.Vectorize).Run()
+	}, wp, idx, global, pr, idx.logger.Write, idx.EmbedDocument).Run()
+}
+
+// IndexLocalFiles indexes every html file under dir, documents are identified by their slash separated path relative to dir.
+func (idx *indexer) IndexLocalFiles(c context.Context, dir string) (int, error) {
+	count := 0
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		if d.IsDir() || (ext != ".html" && ext != ".htm") {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		passages := scraper.ParseHTML(c, string(content), rel, idx.logger.Write)
+		doc := &model.Document{
+			Id: 	sha256.Sum256([]byte(rel)),
+			URL: 	rel,
+		}
+		if err := idx.EmbedDocument(c, doc, passages); err != nil {
+			return fmt.Errorf("error vectorizing file %s: %w", rel, err)
+		}
+		if err := idx.HandleDocumentWords(c, doc, passages); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	return count, err
+}
+
+// SetAnalysis replaces the default analyzer chains with the ones cfg names.
+func (idx *indexer) SetAnalysis(cfg configs.AnalysisConfig) error {
+	analyzers, err := textHandling.NewAnalyzers(cfg)
+	if err != nil {
+		return err
+	}
+	idx.analyzers = analyzers
+	return nil
+}
+
+// CheckEmbeddingDimension fails when the index holds vectors of another size than the provider produces.
+func (idx *indexer) CheckEmbeddingDimension() error {
+	return idx.repository.EnsureEmbeddingDimension(idx.vectorizer.Dimension())
+}
+
+// CheckEmbeddingVersion fails when the vectors of the index were embedded by another version than version.
+func (idx *indexer) CheckEmbeddingVersion(version string) error {
+	return idx.repository.EnsureEmbeddingVersion(version)
+}
+
+// CheckStemmerVersion fails when the words of the index were stemmed by another stemmer than this build's.
+func (idx *indexer) CheckStemmerVersion() error {
+	return idx.repository.EnsureStemmerVersion(textHandling.StemmerVersion)
 }
 
 func (idx *indexer) HandleDocumentWords(c context.Context, doc *model.Document, passages []model.Passage) error {
+	for _, v := range doc.WordVec {
+		if len(v) != idx.vectorizer.Dimension() {
+			return fmt.Errorf("document %s has %d dimensional embedding, index expects %d", doc.URL, len(v), idx.vectorizer.Dimension())
+		}
+	}
+	texts := make([]string, 0, len(passages))
+	for _, passage := range passages {
+		texts = append(texts, passage.Text)
+	}
+	doc.Lang = idx.analyzers.Detect(strings.Join(texts, " "))
+	analyzer := idx.analyzers.Get(doc.Lang)
+
 	var i = 0
 	var sequence []int
-	positions := map[string][]model.Position{}
+	positions := map[int][]model.Position{}
+	words := map[string]struct{}{}
 	for _, passage := range passages {
 		select {
 		case <- c.Done():
 			return fmt.Errorf("context deadline exided")
 		default:
 		}
-		stemmed, err := idx.stemmer.TokenizeAndStem(passage.Text, idx.sc.BreakToNGrams, idx.repository.IndexNGrams)
-		if err != nil {
-			return err
-		}
-		if len(stemmed) == 0 {
+		tokens := analyzer.Analyze(passage.Text)
+		if len(tokens) == 0 {
 			continue
 		}
+		length := tokens[len(tokens) - 1].Pos + 1
+		// SaveToSequence skips empty terms, they go here so ids stay aligned with their tokens
+		tokens = slices.DeleteFunc(tokens, func(token textHandling.Token) bool { return token.Term == "" })
 
-		s, err := idx.repository.SaveToSequence(stemmed...)
+		terms := make([]string, 0, len(tokens))
+		for _, token := range tokens {
+			if token.IsWord() {
+				words[strings.ToLower(token.Text)] = struct{}{}
+			}
+			terms = append(terms, token.Term)
+		}
+		s, err := idx.repository.SaveToSequence(terms...)
 		if err != nil {
 			return err
 		}
 		sequence = append(sequence, s...)
 
-		doc.WordCount += len(s)
-		for _, word := range stemmed {
-			positions[word] = append(positions[word], model.NewTypeTextObj[model.Position](passage.Type, "", i))
-			i++
+		for k, word := range s {
+			positions[word] = append(positions[word], model.NewTypeTextObj[model.Position](passage.Type, "", i + tokens[k].Pos))
 		}
+		doc.WordCount += length
+		i += length
 	}
-	
-	if err := idx.repository.IndexDocumentWords(c, doc.Id, sequence, positions); err != nil {
+
+	distinct := make([]string, 0, len(words))
+	nGrams := make(map[string][]string, len(words))
+	for word := range words {
+		distinct = append(distinct, word)
+		nGrams[word] = idx.sc.BreakToNGrams(word)
+	}
+	if err := idx.repository.IndexNGrams(nGrams); err != nil {
 		return err
 	}
-	if err := idx.repository.SaveDocument(doc); err != nil {
+	if err := idx.setSpellingWords(doc.Id, distinct...); err != nil {
+		return err
+	}
+	if err := idx.repository.IndexDocument(c, doc, sequence, positions); err != nil {
 		return err
 	}
 
-	return nil
+	return idx.addVectors(doc)
 }
 
 func (idx *indexer) HandleTextQuery(text string) ([]int, error) {
-	stemmed, err := idx.stemmer.TokenizeAndStem(text, nil, nil)
+	terms, err := idx.AnalyzeQuery(text, "")
 	if err != nil {
 		return nil, err
 	}
+	sequence := make([]int, 0, len(terms))
+	for _, term := range terms {
+		if len(term.Phrase) > 0 {
+			sequence = append(sequence, term.Phrase...)
+			continue
+		}
+		sequence = append(sequence, term.ID)
+	}
+	return sequence, nil
+}
+
+// AnalyzeQuery stems text with the analyzer of lang, an empty lang is detected from the text itself.
+// Synonyms of the query words come as extra terms at the position of the words they expand.
+func (idx *indexer) AnalyzeQuery(text, lang string) ([]model.QueryTerm, error) {
+	if lang == "" {
+		lang = idx.analyzers.Detect(text)
+	}
+	analyzer := idx.analyzers.Get(lang)
+	tokens := analyzer.Query(text)
+	stemmed := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		stemmed = append(stemmed, token.Term)
+	}
 
 	sequence, err := idx.repository.TransferToSequence(stemmed...)
 	if err != nil {
 		return nil, err
 	}
 
-	for i, word := range sequence {
-		if word == 0 {
-			condidates, err := idx.repository.GetWordsByNGrams(idx.sc.BreakToNGrams(stemmed[i])...)
-			if err != nil || len(condidates) == 0 {
-				continue
+	terms := make([]model.QueryTerm, 0, len(sequence))
+	phrases := 0
+	for i := 0; i < len(sequence); i++ {
+		if tokens[i].PhraseWord() == 1 {
+			// the words of a synonym phrase make one term, with an id no word has
+			end := i + 1
+			for end < len(tokens) && tokens[end].PhraseWord() == end - i + 1 {
+				end++
 			}
-			stemmedReplacement, err := idx.stemmer.TokenizeAndStem(idx.sc.BestReplacement(stemmed[i], condidates), nil, nil)
-			if err != nil || len(stemmedReplacement) == 0 {
-				continue
+			phrases++
+			term := model.QueryTerm{Term: strings.Join(stemmed[i:end], " "), Text: tokens[i].Text, ID: -phrases,
+				Phrase: slices.Clone(sequence[i:end]), Pos: tokens[i].Pos, Weight: tokens[i].Weight}
+			if slices.Contains(term.Phrase, 0) {
+				term.ID = 0
 			}
-			replacementSeq, err := idx.repository.TransferToSequence(stemmedReplacement...)
-			if err != nil || len(replacementSeq) == 0 || replacementSeq[0] == 0 {
-				continue
-			}
-			sequence[i] = replacementSeq[0]
+			terms = append(terms, term)
+			i = end - 1
+			continue
+		}
+		word := sequence[i]
+		term := model.QueryTerm{Term: stemmed[i], Text: tokens[i].Text, ID: word, Pos: tokens[i].Pos, Weight: tokens[i].Weight}
+		if word == 0 && !tokens[i].Derived() && !tokens[i].Typed() {
+			idx.suggest(&term)
+		}
+		terms = append(terms, term)
+	}
+	return terms, nil
+}
+
+// maxSuggestions is the number of "did you mean" words kept per unknown query term.
+const maxSuggestions = 3
+
+// suggest fills the suggestions of a term that is not indexed from the spelling dictionary.
+func (idx *indexer) suggest(term *model.QueryTerm) {
+	for _, s := range idx.sc.Lookup(strings.ToLower(term.Text), maxSuggestions) {
+		if s.Distance > 0 {
+			term.Suggestions = append(term.Suggestions, s.Word)
 		}
 	}
-	return sequence, nil
 }
 
-func (idx *indexer) GetAVGLen() (float64, error) {
-	var wordCount int
-	docs, err := idx.repository.GetAllDocuments()
+// LoadSpelling fills the spell checker from the dictionary stored in the index.
+func (idx *indexer) LoadSpelling() error {
+	return idx.repository.LoadSpellingDictionary(idx.sc.Add)
+}
+
+// Dictionary calls add for every indexed word with the number of documents it occurs in.
+func (idx *indexer) Dictionary(add func(string, int)) error {
+	return idx.repository.LoadSpellingDictionary(add)
+}
+
+// WordsContaining returns the indexed words fragment occurs in, found through the n-gram index.
+// Fragments shorter than an n-gram find nothing.
+func (idx *indexer) WordsContaining(fragment string) ([]string, error) {
+	fragment = strings.ToLower(fragment)
+	nGrams := idx.sc.BreakToNGrams(fragment)
+	if len(nGrams) == 0 {
+		return nil, nil
+	}
+	words, err := idx.repository.GetWordsByNGrams(nGrams[0])
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
+	found := words[:0]
+	for _, word := range words {
+		if strings.Contains(word, fragment) {
+			found = append(found, word)
+		}
+	}
+	return found, nil
+}
+
+// Generation changes whenever a commit changes what a search can find.
+func (idx *indexer) Generation() uint64 {
+	return idx.repository.Generation()
+}
+
+func (idx *indexer) CountQuery(query string) error {
+	return idx.repository.CountQuery(query)
+}
 
-	for _, doc := range docs {
-		wordCount += int(doc.WordCount)
+func (idx *indexer) LoadQueryCounts(add func(string, int)) error {
+	return idx.repository.LoadQueryCounts(add)
+}
+
+// CorpusStats returns the document count, lengths and document frequencies of terms
+// without reading the documents.
+func (idx *indexer) CorpusStats(terms ...int) (*model.CorpusStats, error) {
+	return idx.repository.GetCorpusStats(terms...)
+}
+
+// setSpellingWords counts a document's words in the spelling dictionary, replacing the words
+// an earlier version of it counted.
+func (idx *indexer) setSpellingWords(id [32]byte, words ...string) error {
+	added, removed, err := idx.repository.SetSpellingWords(id, words...)
+	if err != nil {
+		return err
+	}
+	for _, word := range added {
+		idx.sc.Add(word, 1)
 	}
+	for _, word := range removed {
+		idx.sc.Remove(word, 1)
+	}
+	return nil
+}
 
-	return float64(wordCount) / float64(len(docs)), nil
+// DeleteDocument removes a document from the index, the corpus statistics, the spelling dictionary and the vector graph.
+func (idx *indexer) DeleteDocument(id [32]byte) error {
+	if err := idx.repository.DeleteDocument(id); err != nil {
+		return err
+	}
+	if err := idx.setSpellingWords(id); err != nil {
+		return err
+	}
+	return idx.removeVectors(id)
 }
 
 func (idx *indexer) IsCrawledContent(id [32]byte, content []model.Passage) (bool, error) {
@@ -190,19 +410,41 @@ func (idx *indexer) IsCrawledContent(id [32]byte, content []model.Passage) (bool
 	}
 
 	if crawled {
+		if doc.WordVec, err = idx.repository.GetEmbeddings(doc.Id); err != nil {
+			return true, err
+		}
+		if doc.Text, doc.Chunks, err = idx.repository.GetChunks(doc.Id); err != nil {
+			return true, err
+		}
 		doc.Id = id
 		if err := idx.repository.SaveDocument(doc); err != nil {
 			return true, err
 		}
+		if err := idx.addVectors(doc); err != nil {
+			return true, err
+		}
 	}
 
 	return crawled, err
 }
 
+// Languages lists the codes of the languages the indexer has analyzers for.
+func (idx *indexer) Languages() []string {
+	return idx.analyzers.Languages()
+}
+
 func (idx *indexer) GetDocumentByID(id [32]byte) (*model.Document, error) {
 	return idx.repository.GetDocumentByID(id)
 }
 
+func (idx *indexer) GetEmbeddings(id [32]byte) ([][]float64, error) {
+	return idx.repository.GetEmbeddings(id)
+}
+
+func (idx *indexer) GetChunks(id [32]byte) (string, []model.Chunk, error) {
+	return idx.repository.GetChunks(id)
+}
+
 func (idx *indexer) GetDocumentsCount() (int, error) {
 	return idx.repository.GetDocumentsCount()
 }
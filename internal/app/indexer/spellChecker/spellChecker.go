@@ -1,67 +1,194 @@
 package spellChecker
 
+import (
+	"sort"
+	"sync"
+)
+
+// prefixLength bounds the part of a word delete variants are generated from, longer words
+// are told apart by the distance check on the whole word.
+const prefixLength = 7
+
+// SpellChecker is a symmetric delete (SymSpell) correction index: every dictionary word is
+// stored under the variants of its prefix with up to maxTypo characters deleted, a misspelling
+// shares one of those variants with every word within maxTypo edits of it.
 type SpellChecker struct {
+	mu 			sync.RWMutex
 	maxTypo     int
     nGramCount  int
+	freq 		map[string]int
+	deletes 	map[string][]string
+	longest 	int
 }
 
 func NewSpellChecker(maxTypoLen, nGramCount int) *SpellChecker {
 	return &SpellChecker{
 		maxTypo: maxTypoLen,
         nGramCount: nGramCount,
+		freq: map[string]int{},
+		deletes: map[string][]string{},
 	}
 }
 
-type token struct {
-    s       string
-    score   int
+// Suggestion is a dictionary word close to a misspelling, Freq is the number of documents it occurs in.
+type Suggestion struct {
+	Word 		string
+	Distance 	int
+	Freq 		int
 }
 
-func (s *SpellChecker) BestReplacement(s1 string, candidates []string) string {
-    st := []token{}
-	for _, candidate := range candidates {
-		distance := s.levenshteinDistance(s1, candidate)
-		if len(st) == 0 || distance < st[len(st) - 1].score {
-            st = append(st, token{
-                s: candidate,
-                score: distance,
-            })
-        }
-	}
-	return st[len(st) - 1].s
+// Add counts df more documents for word, new words are added to the delete index.
+func (sc *SpellChecker) Add(word string, df int) {
+	if word == "" || df <= 0 {
+		return
+	}
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if _, ok := sc.freq[word]; ok {
+		sc.freq[word] += df
+		return
+	}
+	sc.freq[word] = df
+	sc.longest = max(sc.longest, len([]rune(word)))
+	for variant := range sc.variants(word) {
+		sc.deletes[variant] = append(sc.deletes[variant], word)
+	}
 }
 
-func (s *SpellChecker) levenshteinDistance(word1 string, word2 string) int {
-    w1, w2 := len(word1), len(word2)
-    if w1 - w2 > s.maxTypo || w2 - w1 > s.maxTypo {
-        return s.maxTypo + 1
-    }
-    dp := make([][]int, w1 + 1)
-    for i := range w1 + 1 {
-        dp[i] = make([]int, w2 + 1)
-    }
+// Remove takes df documents off the count of word, words no document has left are dropped.
+func (sc *SpellChecker) Remove(word string, df int) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	count, ok := sc.freq[word]
+	if !ok || df <= 0 {
+		return
+	}
+	if count > df {
+		sc.freq[word] = count - df
+		return
+	}
+	delete(sc.freq, word)
+	for variant := range sc.variants(word) {
+		words := sc.deletes[variant]
+		for i, w := range words {
+			if w == word {
+				words = append(words[:i], words[i + 1:]...)
+				break
+			}
+		}
+		if len(words) == 0 {
+			delete(sc.deletes, variant)
+		} else {
+			sc.deletes[variant] = words
+		}
+	}
+}
 
-    for i := 1; i <= w1; i++ {
-        dp[i][0] = i
-    }
-    for j := 1; j <= w2; j++ {
-        dp[0][j] = j
-    }
+// Lookup returns up to n dictionary words within maxTypo edits of word, closest first
+// and the more frequent first among equally close ones. A known word is its only suggestion.
+func (sc *SpellChecker) Lookup(word string, n int) []Suggestion {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	if df, ok := sc.freq[word]; ok {
+		return []Suggestion{{Word: word, Freq: df}}
+	}
+	runes := []rune(word)
+	if len(runes) - sc.maxTypo > sc.longest {
+		return nil
+	}
 
-    for i := 1; i <= w1; i++ {
-        for j := 1; j <= w2; j++ {
-            if word1[i - 1] == word2[j - 1] {
-                dp[i][j] = dp[i - 1][j - 1]
-            } else {
-                insert := dp[i - 1][j]
-                delete := dp[i][j - 1]
-                replace := dp[i - 1][j - 1]
-                dp[i][j] = min(delete, insert, replace) + 1
-            }
-        }
-    }
+	seen := map[string]struct{}{}
+	var suggestions []Suggestion
+	for variant := range sc.variants(word) {
+		for _, candidate := range sc.deletes[variant] {
+			if _, ok := seen[candidate]; ok {
+				continue
+			}
+			seen[candidate] = struct{}{}
+			if d := damerauLevenshtein(runes, []rune(candidate), sc.maxTypo); d <= sc.maxTypo {
+				suggestions = append(suggestions, Suggestion{Word: candidate, Distance: d, Freq: sc.freq[candidate]})
+			}
+		}
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Distance != suggestions[j].Distance {
+			return suggestions[i].Distance < suggestions[j].Distance
+		}
+		if suggestions[i].Freq != suggestions[j].Freq {
+			return suggestions[i].Freq > suggestions[j].Freq
+		}
+		return suggestions[i].Word < suggestions[j].Word
+	})
+	return suggestions[:min(n, len(suggestions))]
+}
+
+// variants returns the prefix of word with every combination of up to maxTypo runes deleted.
+func (sc *SpellChecker) variants(word string) map[string]struct{} {
+	runes := []rune(word)
+	if len(runes) > prefixLength {
+		runes = runes[:prefixLength]
+	}
+	out := map[string]struct{}{string(runes): {}}
+	level := [][]rune{runes}
+	for range sc.maxTypo {
+		var next [][]rune
+		for _, v := range level {
+			if len(v) <= 1 {
+				continue
+			}
+			for i := range v {
+				d := make([]rune, 0, len(v) - 1)
+				d = append(append(d, v[:i]...), v[i+1:]...)
+				if _, ok := out[string(d)]; !ok {
+					out[string(d)] = struct{}{}
+					next = append(next, d)
+				}
+			}
+		}
+		level = next
+	}
+	return out
+}
 
-    return dp[w1][w2]
+// damerauLevenshtein is the optimal string alignment distance of a and b, adjacent transpositions
+// count as one edit. Anything above maxDist is reported as maxDist + 1.
+func damerauLevenshtein(a, b []rune, maxDist int) int {
+	if abs(len(a) - len(b)) > maxDist {
+		return maxDist + 1
+	}
+	prev2 := make([]int, len(b) + 1)
+	prev := make([]int, len(b) + 1)
+	cur := make([]int, len(b) + 1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j] + 1, cur[j-1] + 1, prev[j-1] + cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				cur[j] = min(cur[j], prev2[j-2] + 1)
+			}
+			rowMin = min(rowMin, cur[j])
+		}
+		if rowMin > maxDist {
+			return maxDist + 1
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return min(prev[len(b)], maxDist + 1)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
 }
 
 func (sc *SpellChecker) BreakToNGrams(word string) []string {
@@ -76,4 +203,4 @@ func (sc *SpellChecker) BreakToNGrams(word string) []string {
         nGrams = append(nGrams, string(nGram))
     }
     return nGrams
-}
\ No newline at end of file
+}
@@ -0,0 +1,150 @@
+package spellChecker
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestVariants(t *testing.T) {
+	sc := NewSpellChecker(1, 3)
+	got := keys(sc.variants("cat"))
+	want := []string{"at", "ca", "cat", "ct"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("variants(cat) = %v, want %v", got, want)
+	}
+
+	// only the first prefixLength runes are varied
+	for v := range sc.variants("abcdefghij") {
+		if len([]rune(v)) > prefixLength {
+			t.Fatalf("variant %q is longer than the prefix", v)
+		}
+	}
+}
+
+func TestAddIndexesDeletes(t *testing.T) {
+	sc := NewSpellChecker(1, 3)
+	sc.Add("cat", 2)
+	sc.Add("cut", 1)
+	// both words lose a rune to "ct"
+	if got := sc.deletes["ct"]; !reflect.DeepEqual(got, []string{"cat", "cut"}) {
+		t.Fatalf("deletes[ct] = %v", got)
+	}
+	sc.Add("cat", 3)
+	if sc.freq["cat"] != 5 || len(sc.deletes["ct"]) != 2 {
+		t.Fatalf("adding a known word again: freq %d, deletes[ct] %v", sc.freq["cat"], sc.deletes["ct"])
+	}
+
+	sc.Remove("cat", 5)
+	if _, ok := sc.freq["cat"]; ok {
+		t.Fatal("cat is still counted")
+	}
+	if got := sc.deletes["ct"]; !reflect.DeepEqual(got, []string{"cut"}) {
+		t.Fatalf("deletes[ct] after removing cat = %v", got)
+	}
+	if _, ok := sc.deletes["ca"]; ok {
+		t.Fatal("variant only cat had is still indexed")
+	}
+}
+
+func TestDamerauLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b 	string
+		max 	int
+		want 	int
+	}{
+		{"search", "search", 2, 0},
+		{"serach", "search", 2, 1}, // transposition
+		{"saerch", "search", 2, 1},
+		{"seach", "search", 2, 1},  // deletion
+		{"searchh", "search", 2, 1}, // insertion
+		{"sezrch", "search", 2, 1},  // substitution
+		{"srarch", "search", 2, 1},
+		{"ca", "abc", 3, 3},         // optimal string alignment doesn't edit a transposed pair again
+		{"kitten", "sitting", 3, 3},
+		{"kitten", "sitting", 2, 3}, // above max reports max + 1
+		{"a", "abcd", 1, 2},
+		{"", "ab", 2, 2},
+	}
+	for _, tt := range tests {
+		if got := damerauLevenshtein([]rune(tt.a), []rune(tt.b), tt.max); got != tt.want {
+			t.Errorf("damerauLevenshtein(%q, %q, %d) = %d, want %d", tt.a, tt.b, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestLookupOrder(t *testing.T) {
+	sc := NewSpellChecker(2, 3)
+	sc.Add("search", 10)
+	sc.Add("starch", 50)
+	sc.Add("bleach", 99)
+	sc.Add("sea", 1)
+	sc.Add("reach", 7)
+	sc.Add("peach", 7)
+
+	// search is one transposition from serach, starch two edits, distance decides before
+	// frequency so the rarer search comes first
+	got := sc.Lookup("serach", 10)
+	if len(got) < 2 || got[0] != (Suggestion{Word: "search", Distance: 1, Freq: 10}) || got[1] != (Suggestion{Word: "starch", Distance: 2, Freq: 50}) {
+		t.Fatalf("Lookup(serach) = %+v, want search then starch", got)
+	}
+	for i := 1; i < len(got); i++ {
+		a, b := got[i-1], got[i]
+		if a.Distance > b.Distance || a.Distance == b.Distance && (a.Freq < b.Freq || a.Freq == b.Freq && a.Word > b.Word) {
+			t.Fatalf("Lookup(serach) = %+v is not ordered by distance, frequency and word", got)
+		}
+	}
+
+	// bleach is one insertion from leach, peach and reach one substitution,
+	// equal distance goes by frequency and equal frequency by the word
+	got = sc.Lookup("leach", 3)
+	want := []Suggestion{{"bleach", 1, 99}, {"peach", 1, 7}, {"reach", 1, 7}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Lookup(leach) = %+v, want %+v", got, want)
+	}
+	if got := sc.Lookup("leach", 1); len(got) != 1 || got[0].Word != "bleach" {
+		t.Fatalf("Lookup(leach, 1) = %+v", got)
+	}
+}
+
+func TestLookupKnownWord(t *testing.T) {
+	sc := NewSpellChecker(2, 3)
+	sc.Add("search", 4)
+	sc.Add("searches", 9)
+	want := []Suggestion{{Word: "search", Freq: 4}}
+	if got := sc.Lookup("search", 5); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Lookup(search) = %+v, want %+v", got, want)
+	}
+}
+
+func TestLookupNoCandidates(t *testing.T) {
+	sc := NewSpellChecker(1, 3)
+	if got := sc.Lookup("anything", 5); len(got) != 0 {
+		t.Fatalf("Lookup on an empty dictionary = %+v", got)
+	}
+	sc.Add("cat", 1)
+	for _, word := range []string{"dog", "catalogue", ""} {
+		if got := sc.Lookup(word, 5); len(got) != 0 {
+			t.Errorf("Lookup(%q) = %+v, want no suggestions", word, got)
+		}
+	}
+}
+
+func TestBreakToNGrams(t *testing.T) {
+	sc := NewSpellChecker(1, 3)
+	if got := sc.BreakToNGrams("поиск"); !reflect.DeepEqual(got, []string{"пои", "оис", "иск"}) {
+		t.Fatalf("BreakToNGrams(поиск) = %v", got)
+	}
+	if got := sc.BreakToNGrams("go"); got != nil {
+		t.Fatalf("BreakToNGrams(go) = %v, want nil", got)
+	}
+}
+
+func keys(m map[string]struct{}) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,268 @@
+package textHandling
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/box1bs/monocle/configs"
+)
+
+const DefaultLanguage = "en"
+
+// defaultChain is used for every language the analysis config does not name.
+var defaultChain = configs.AnalyzerConfig{
+	CharFilters: 	[]string{"html_entity", "nfkc"},
+	Tokenizer: 		"standard",
+	Filters: 		[]string{"lowercase", "stopword", "synonym", "stemmer"},
+}
+
+// Token is a term produced by an analyzer. Text is the token as the tokenizer cut it and Term
+// what the filters made of it. Tokens sharing Pos are alternatives of one another, terms the
+// filters derived from others, like synonyms, may weigh less than 1.
+type Token struct {
+	Text 	string
+	Term 	string
+	Pos 	int
+	Weight 	float64
+	kind 	tokenType
+	phrase 	int
+}
+
+// IsWord reports whether the token is a plain word of the text rather than a number, an entity
+// or a term a filter derived from other tokens.
+func (t Token) IsWord() bool {
+	return t.kind == WORD
+}
+
+// Derived reports whether the token was made up from other tokens, like a synonym,
+// a shingle or a part of an identifier.
+func (t Token) Derived() bool {
+	return t.kind == SYNONYM || t.kind == SHINGLE || t.kind == SUBWORD
+}
+
+// PhraseWord is the place of the token in a synonym of several words, counted from 1,
+// the words of such a synonym only match next to each other. Other tokens are at 0.
+func (t Token) PhraseWord() int {
+	return t.phrase
+}
+
+// Analyzer runs text through char filters, a tokenizer and token filters, in that order.
+type Analyzer struct {
+	lang 		string
+	chars 		[]charFilter
+	tokenize 	tokenizerFunc
+	filters 	[]tokenFilter
+	queryOnly 	[]bool
+}
+
+func (a *Analyzer) Language() string {
+	return a.lang
+}
+
+// Analyze returns the tokens of document text with positions renumbered to be dense,
+// so removed tokens leave no gaps.
+func (a *Analyzer) Analyze(text string) []Token {
+	return a.analyze(text, false)
+}
+
+// Query analyzes query text, unlike Analyze it also runs the query only filters.
+// Field clauses, like email:a@b.org, skip the chain and follow the other terms.
+func (a *Analyzer) Query(text string) []Token {
+	text, fields := fieldTokens(text)
+	tokens := a.analyze(text, true)
+	for _, t := range fields {
+		t.Pos = len(tokens)
+		if len(tokens) > 0 {
+			t.Pos = tokens[len(tokens) - 1].Pos + 1
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens
+}
+
+func (a *Analyzer) analyze(text string, query bool) []Token {
+	for _, f := range a.chars {
+		text = f(text)
+	}
+	tokens := a.tokenize(text)
+	for i, f := range a.filters {
+		if a.queryOnly[i] && !query {
+			continue
+		}
+		tokens = f(tokens)
+	}
+	pos, last := -1, -1
+	for i := range tokens {
+		if tokens[i].Pos != last {
+			last = tokens[i].Pos
+			pos++
+		}
+		tokens[i].Pos = pos
+	}
+	return tokens
+}
+
+// Terms is Analyze keeping only the terms.
+func (a *Analyzer) Terms(text string) []string {
+	tokens := a.Analyze(text)
+	terms := make([]string, 0, len(tokens))
+	for _, t := range tokens {
+		terms = append(terms, t.Term)
+	}
+	return terms
+}
+
+// Analyzers is the registry of analyzers by ISO 639-1 language code.
+type Analyzers struct {
+	byLang map[string]*Analyzer
+}
+
+// NewAnalyzers builds an analyzer for every supported language from the chains named in cfg.
+func NewAnalyzers(cfg configs.AnalysisConfig) (*Analyzers, error) {
+	a := &Analyzers{byLang: map[string]*Analyzer{}}
+	for lang := range cfg.Analyzers {
+		if _, ok := languages[lang]; !ok {
+			return nil, fmt.Errorf("analysis: unsupported language %q", lang)
+		}
+	}
+	synonyms, err := loadSynonyms(cfg)
+	if err != nil {
+		return nil, err
+	}
+	res := &resources{synonyms: synonyms, synonymWeight: cfg.SynonymWeight}
+	if res.synonymWeight <= 0 {
+		res.synonymWeight = defaultSynonymWeight
+	}
+	for lang := range languages {
+		chain, ok := cfg.Analyzers[lang]
+		if !ok {
+			chain = defaultChain
+		}
+		an, err := newAnalyzer(lang, chain, res)
+		if err != nil {
+			return nil, fmt.Errorf("analysis %s: %w", lang, err)
+		}
+		a.byLang[lang] = an
+	}
+	return a, nil
+}
+
+// DefaultAnalyzers uses the default chain for every language.
+func DefaultAnalyzers() *Analyzers {
+	a, err := NewAnalyzers(configs.AnalysisConfig{})
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+func newAnalyzer(lang string, chain configs.AnalyzerConfig, res *resources) (*Analyzer, error) {
+	an := &Analyzer{lang: lang}
+	for _, name := range chain.CharFilters {
+		f, ok := charFilters[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown char filter %q", name)
+		}
+		an.chars = append(an.chars, f)
+	}
+	name := chain.Tokenizer
+	if name == "" {
+		name = defaultChain.Tokenizer
+	}
+	t, ok := tokenizers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown tokenizer %q", name)
+	}
+	an.tokenize = t()
+	for _, name := range chain.Filters {
+		newFilter, ok := tokenFilters[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown token filter %q", name)
+		}
+		f, err := newFilter(lang, res)
+		if err != nil {
+			return nil, err
+		}
+		an.filters = append(an.filters, f)
+		an.queryOnly = append(an.queryOnly, queryFilters[name])
+	}
+	return an, nil
+}
+
+// Get returns the analyzer of lang, unknown languages are analyzed as English.
+func (a *Analyzers) Get(lang string) *Analyzer {
+	if an, ok := a.byLang[lang]; ok {
+		return an
+	}
+	return a.byLang[DefaultLanguage]
+}
+
+func (a *Analyzers) Languages() []string {
+	langs := make([]string, 0, len(a.byLang))
+	for lang := range a.byLang {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// detectLimit caps the number of words Detect looks at. detectMinScore is the score latin text needs
+// to leave DefaultLanguage, a stop word scores 2 and a diacritic 1, so a single stop word shared by
+// an English query ("die hard") isn't enough.
+const (
+	detectLimit = 2000
+	detectMinScore = 3
+)
+
+// Detect guesses the language of text: mostly cyrillic text is Russian, latin text
+// goes to the language whose stop words and diacritics it contains the most of.
+// Text with less signal than detectMinScore is reported as DefaultLanguage.
+func (a *Analyzers) Detect(text string) string {
+	var cyrillic, latin int
+	scores := map[string]int{}
+	words := 0
+	for _, field := range strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) && r != '\'' }) {
+		if words++; words > detectLimit {
+			break
+		}
+		word := strings.ToLower(field)
+		for _, r := range word {
+			switch {
+			case unicode.Is(unicode.Cyrillic, r):
+				cyrillic++
+			case unicode.Is(unicode.Latin, r):
+				latin++
+			}
+			if lang, ok := diacriticHints[r]; ok {
+				scores[lang]++
+			}
+		}
+		for _, lang := range latinLanguages {
+			if languages[lang].stopWords.isStopWord(word) {
+				scores[lang] += 2
+			}
+		}
+	}
+	if cyrillic > latin {
+		return "ru"
+	}
+
+	best, bestScore := DefaultLanguage, detectMinScore - 1
+	for _, lang := range latinLanguages {
+		if scores[lang] > bestScore {
+			best, bestScore = lang, scores[lang]
+		}
+	}
+	return best
+}
+
+// latinLanguages is ordered so that ties go to English.
+var latinLanguages = []string{"en", "de", "fr", "es"}
+
+var diacriticHints = map[rune]string{
+	'ñ': "es", 'á': "es", 'í': "es", 'ó': "es", 'ú': "es",
+	'ß': "de", 'ä': "de", 'ö': "de", 'ü': "de",
+	'ç': "fr", 'è': "fr", 'ê': "fr", 'à': "fr", 'â': "fr", 'ô': "fr", 'œ': "fr",
+}
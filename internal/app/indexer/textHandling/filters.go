@@ -0,0 +1,167 @@
+package textHandling
+
+import (
+	"html"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+type charFilter func(string) string
+
+type tokenizerFunc func(string) []Token
+
+type tokenFilter func([]Token) []Token
+
+// charFilters rewrite the whole text before it is tokenized.
+var charFilters = map[string]charFilter{
+	"html_entity": 	html.UnescapeString,
+	"nfkc": 		norm.NFKC.String,
+	"accent_fold": 	foldAccents,
+}
+
+var tokenizers = map[string]func() tokenizerFunc{
+	"standard": 	standardTokenizer,
+	"whitespace": 	func() tokenizerFunc { return whitespaceTokenizer },
+}
+
+// resources are loaded once and shared by the filters of all analyzers.
+type resources struct {
+	synonyms 		[]synonymRule
+	synonymWeight 	float64
+}
+
+// tokenFilters build a filter for the language of the analyzer.
+var tokenFilters = map[string]func(string, *resources) (tokenFilter, error){
+	"lowercase": 	func(string, *resources) (tokenFilter, error) { return lowercaseFilter, nil },
+	"stopword": 	newStopWordFilter,
+	"stemmer": 		newStemmerFilter,
+	"synonym": 		newSynonymFilter,
+	"shingle": 		func(string, *resources) (tokenFilter, error) { return shingleFilter, nil },
+}
+
+// queryFilters only run on queries, expanding documents as well would match every synonym twice.
+var queryFilters = map[string]bool{
+	"synonym": true,
+}
+
+func foldAccents(text string) string {
+	folded, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), text)
+	if err != nil {
+		return text
+	}
+	return folded
+}
+
+// standardKinds are the token types the standard tokenizer emits, the rest is dropped.
+var standardKinds = map[tokenType]bool{
+	WORD: 			true,
+	ALPHANUMERIC: 	true,
+	CJK: 			true,
+	IDENTIFIER: 	true,
+	VERSION: 		true,
+	TECH_TERM: 		true,
+	HASHTAG: 		true,
+	MENTION: 		true,
+	FILE_PATH: 		true,
+	EMAIL_ADDR: 	true,
+	URL_ADDR: 		true,
+	IP_V4_ADDR: 	true,
+	IP_V6_ADDR: 	true,
+	NUMBER: 		true,
+}
+
+// standardTokenizer keeps words, mixed letter and digit tokens and the entities the rules recognize.
+// Parts of identifiers, hashtags, paths and the like follow them at the same position, emails,
+// urls, ip addresses and numbers become typed terms.
+func standardTokenizer() tokenizerFunc {
+	t := newTokenizer()
+	return func(text string) []Token {
+		tokens := []Token{}
+		pos := 0
+		for _, tok := range t.entityTokenize(text) {
+			if !standardKinds[tok.Type] || len(tok.Value) == 0 {
+				continue
+			}
+			tokens = append(tokens, Token{Text: tok.Value, Term: typedTerm(tok.Type, tok.Value), Pos: pos, Weight: 1, kind: tok.Type})
+			for _, part := range subTokens(tok) {
+				tokens = append(tokens, Token{Text: part, Term: part, Pos: pos, Weight: 1, kind: SUBWORD})
+			}
+			pos++
+		}
+		return tokens
+	}
+}
+
+func whitespaceTokenizer(text string) []Token {
+	fields := strings.Fields(text)
+	tokens := make([]Token, 0, len(fields))
+	for i, field := range fields {
+		tokens = append(tokens, Token{Text: field, Term: field, Pos: i, Weight: 1, kind: WORD})
+	}
+	return tokens
+}
+
+func lowercaseFilter(tokens []Token) []Token {
+	for i := range tokens {
+		tokens[i].Term = strings.ToLower(tokens[i].Term)
+	}
+	return tokens
+}
+
+func newStopWordFilter(lang string, _ *resources) (tokenFilter, error) {
+	sw := languages[lang].stopWords
+	return func(tokens []Token) []Token {
+		kept := tokens[:0]
+		for _, t := range tokens {
+			if !stemmable[t.kind] || !sw.isStopWord(t.Term) {
+				kept = append(kept, t)
+			}
+		}
+		return kept
+	}, nil
+}
+
+// stemmable are the token types stop words are removed from and the stemmer works on,
+// entities and mixed letter and digit tokens are kept as they are.
+var stemmable = map[tokenType]bool{
+	WORD: 		true,
+	SUBWORD: 	true,
+	SYNONYM: 	true,
+}
+
+func newStemmerFilter(lang string, _ *resources) (tokenFilter, error) {
+	l := languages[lang]
+	return func(tokens []Token) []Token {
+		for i := range tokens {
+			if stemmable[tokens[i].kind] {
+				tokens[i].Term = l.stemWord(tokens[i].Term)
+			}
+		}
+		return tokens
+	}, nil
+}
+
+// shingleFilter adds a two word term at the position of every word followed directly by another.
+func shingleFilter(tokens []Token) []Token {
+	out := make([]Token, 0, 2 * len(tokens))
+	for i, t := range tokens {
+		out = append(out, t)
+		if t.Derived() {
+			continue
+		}
+		for _, next := range tokens[i+1:] {
+			if next.Pos > t.Pos + 1 {
+				break
+			}
+			if next.Pos == t.Pos + 1 && !next.Derived() {
+				out = append(out, Token{Text: t.Text + " " + next.Text, Term: t.Term + " " + next.Term, Pos: t.Pos, Weight: 1, kind: SHINGLE})
+				break
+			}
+		}
+	}
+	return out
+}
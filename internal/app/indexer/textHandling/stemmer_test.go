@@ -0,0 +1,42 @@
+package textHandling
+
+import (
+	"bufio"
+	"os"
+	"testing"
+)
+
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var lines []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return lines
+}
+
+// testdata/english holds words in voc.txt and their Porter2 stems in output.txt, line by line,
+// laid out like the Snowball test data. They are a subset picked from the algorithm's description,
+// not the official lists; testdata/english/README says which words and how to use the full lists.
+func TestEnglishStemmerSnowballVocabulary(t *testing.T) {
+	words := readLines(t, "testdata/english/voc.txt")
+	stems := readLines(t, "testdata/english/output.txt")
+	if len(words) != len(stems) {
+		t.Fatalf("voc.txt has %d words, output.txt %d stems", len(words), len(stems))
+	}
+	en := languages["en"]
+	for i, word := range words {
+		if got := en.stemWord(word); got != stems[i] {
+			t.Errorf("stem(%q) = %q, want %q", word, got, stems[i])
+		}
+	}
+}
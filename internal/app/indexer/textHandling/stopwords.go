@@ -0,0 +1,74 @@
+package textHandling
+
+var englishStopWords = []string{
+	"a", "an", "and", "are", "as", "at", "be", "by", "for", "from",
+	"has", "he", "in", "is", "it", "its", "of", "on", "that", "the",
+	"to", "was", "were", "will", "with", "this", "but", "they",
+	"have", "had", "what", "when", "where", "who", "which", "why",
+	"how", "all", "any", "both", "each", "few", "more", "most",
+	"other", "some", "such", "no", "nor", "not", "only", "own",
+	"same", "so", "than", "too", "very",
+}
+
+// the lists below are trimmed versions of the Snowball ones.
+
+var russianStopWords = []string{
+	"и", "в", "во", "не", "что", "он", "на", "я", "с", "со", "как", "а", "то",
+	"все", "она", "так", "его", "но", "да", "ты", "к", "у", "же", "вы", "за",
+	"бы", "по", "только", "ее", "мне", "было", "вот", "от", "меня", "еще", "нет",
+	"о", "из", "ему", "теперь", "когда", "даже", "ну", "вдруг", "ли", "если",
+	"уже", "или", "ни", "быть", "был", "него", "до", "вас", "нибудь", "опять",
+	"уж", "вам", "ведь", "там", "потом", "себя", "ничего", "ей", "может", "они",
+	"тут", "где", "есть", "надо", "ней", "для", "мы", "тебя", "их", "чем", "была",
+	"сам", "чтоб", "без", "будто", "чего", "раз", "тоже", "себе", "под", "будет",
+	"ж", "тогда", "кто", "этот", "того", "потому", "этого", "какой", "совсем",
+	"ним", "здесь", "этом", "один", "почти", "мой", "тем", "чтобы", "нее", "были",
+	"куда", "зачем", "всех", "никогда", "можно", "при", "наконец", "два", "об",
+	"другой", "хоть", "после", "над", "больше", "тот", "через", "эти", "нас",
+	"про", "всего", "них", "какая", "много", "разве", "три", "эту", "моя",
+	"впрочем", "хорошо", "свою", "этой", "перед", "иногда", "лучше", "чуть",
+	"том", "нельзя", "такой", "им", "более", "всегда", "конечно", "всю", "между",
+	"это", "также", "которые", "который", "которая", "которое",
+}
+
+var germanStopWords = []string{
+	"aber", "alle", "allem", "allen", "aller", "alles", "als", "also", "am", "an",
+	"ander", "andere", "anderem", "anderen", "anderer", "anderes", "auch", "auf",
+	"aus", "bei", "bin", "bis", "bist", "da", "damit", "dann", "der", "den", "des",
+	"dem", "die", "das", "dass", "daß", "dich", "dir", "du", "dies", "diese",
+	"diesem", "diesen", "dieser", "dieses", "doch", "dort", "durch", "ein", "eine",
+	"einem", "einen", "einer", "eines", "er", "es", "euch", "euer", "für", "hat",
+	"hatte", "hatten", "hier", "hin", "ich", "ihm", "ihn", "ihr", "ihre", "im",
+	"in", "ist", "jede", "jedem", "jeden", "jeder", "jedes", "kann", "kein",
+	"keine", "man", "mich", "mir", "mit", "muss", "nach", "nicht", "nichts",
+	"noch", "nun", "nur", "ob", "oder", "ohne", "sehr", "sein", "seine", "sich",
+	"sie", "sind", "so", "solche", "soll", "sondern", "über", "um", "und", "uns",
+	"unter", "viel", "vom", "von", "vor", "während", "war", "waren", "was",
+	"weil", "welche", "wenn", "werden", "wie", "wieder", "will", "wir", "wird",
+	"wo", "zu", "zum", "zur", "zwar", "zwischen",
+}
+
+var frenchStopWords = []string{
+	"au", "aux", "avec", "ce", "ces", "dans", "de", "des", "du", "elle", "en",
+	"et", "eux", "il", "ils", "je", "la", "le", "les", "leur", "lui", "ma",
+	"mais", "me", "même", "mes", "moi", "mon", "ne", "nos", "notre", "nous",
+	"on", "ou", "par", "pas", "pour", "qu", "que", "qui", "sa", "se", "ses",
+	"son", "sur", "ta", "te", "tes", "toi", "ton", "tu", "un", "une", "vos",
+	"votre", "vous", "c", "d", "j", "l", "à", "m", "n", "s", "t", "y", "été",
+	"est", "sont", "était", "être", "avoir", "ai", "as", "a", "ont", "avait",
+	"cette", "cet", "ceci", "cela", "comme", "plus", "où", "donc", "si", "tout",
+	"tous", "aussi", "très",
+}
+
+var spanishStopWords = []string{
+	"de", "la", "que", "el", "en", "y", "a", "los", "del", "se", "las", "por",
+	"un", "para", "con", "no", "una", "su", "al", "lo", "como", "más", "pero",
+	"sus", "le", "ya", "o", "este", "sí", "porque", "esta", "entre", "cuando",
+	"muy", "sin", "sobre", "también", "me", "hasta", "hay", "donde", "quien",
+	"desde", "todo", "nos", "durante", "todos", "uno", "les", "ni", "contra",
+	"otros", "ese", "eso", "ante", "ellos", "e", "esto", "mí", "antes", "algunos",
+	"qué", "unos", "yo", "otro", "otras", "otra", "él", "tanto", "esa", "estos",
+	"mucho", "quienes", "nada", "muchos", "cual", "poco", "ella", "estar",
+	"estas", "algunas", "algo", "nosotros", "mi", "mis", "tú", "te", "ti", "tu",
+	"tus", "ellas", "es", "son", "fue", "era", "ser", "está", "están", "ha", "han",
+}
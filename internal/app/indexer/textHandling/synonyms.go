@@ -0,0 +1,214 @@
+package textHandling
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/box1bs/monocle/configs"
+)
+
+const defaultSynonymWeight = 0.5
+
+// synonymRule maps a phrase to the phrases it expands to, phrases are lists of lowercase words.
+type synonymRule struct {
+	from 	[]string
+	to 		[][]string
+}
+
+// loadSynonyms reads the inline rules and rule files of cfg, both in Solr syntax unless
+// a file is marked as WordNet.
+func loadSynonyms(cfg configs.AnalysisConfig) ([]synonymRule, error) {
+	rules, err := parseSolrSynonyms(strings.NewReader(strings.Join(cfg.Synonyms, "\n")))
+	if err != nil {
+		return nil, fmt.Errorf("inline synonyms: %w", err)
+	}
+	for _, f := range cfg.SynonymFiles {
+		file, err := os.Open(f.Path)
+		if err != nil {
+			return nil, err
+		}
+		var fileRules []synonymRule
+		switch f.Format {
+		case "", "solr":
+			fileRules, err = parseSolrSynonyms(file)
+		case "wordnet":
+			fileRules, err = parseWordNetSynonyms(file)
+		default:
+			err = fmt.Errorf("unknown format %q", f.Format)
+		}
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("synonyms %s: %w", f.Path, err)
+		}
+		rules = append(rules, fileRules...)
+	}
+	return rules, nil
+}
+
+// parseSolrSynonyms reads Solr synonym lines: "a, b, c" makes every phrase expand to the
+// others, "a, b => c, d" expands a and b to c and d but not the other way round.
+func parseSolrSynonyms(r io.Reader) ([]synonymRule, error) {
+	var rules []synonymRule
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if left, right, oneWay := strings.Cut(text, "=>"); oneWay {
+			from, to := splitPhrases(left), splitPhrases(right)
+			if len(from) == 0 || len(to) == 0 {
+				return nil, fmt.Errorf("line %d: empty side in %q", line, text)
+			}
+			for _, phrase := range from {
+				rules = append(rules, synonymRule{from: phrase, to: to})
+			}
+			continue
+		}
+		rules = append(rules, equivalent(splitPhrases(text))...)
+	}
+	return rules, scanner.Err()
+}
+
+// parseWordNetSynonyms reads the s(...) facts of the WordNet prolog database,
+// the words of one synset are equivalent.
+func parseWordNetSynonyms(r io.Reader) ([]synonymRule, error) {
+	var rules []synonymRule
+	var synset string
+	var words [][]string
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(text, "s(") {
+			continue
+		}
+		id, rest, ok := strings.Cut(text[2:], ",")
+		start, end := strings.Index(rest, "'"), strings.LastIndex(rest, "'")
+		if !ok || start < 0 || end <= start {
+			return nil, fmt.Errorf("line %d: malformed synset entry %q", line, text)
+		}
+		if id != synset {
+			rules = append(rules, equivalent(words)...)
+			synset, words = id, nil
+		}
+		word := strings.ReplaceAll(rest[start+1:end], "''", "'")
+		if phrase := strings.Fields(strings.ToLower(word)); len(phrase) > 0 {
+			words = append(words, phrase)
+		}
+	}
+	rules = append(rules, equivalent(words)...)
+	return rules, scanner.Err()
+}
+
+func splitPhrases(s string) [][]string {
+	var phrases [][]string
+	for _, part := range strings.Split(s, ",") {
+		if phrase := strings.Fields(strings.ToLower(part)); len(phrase) > 0 {
+			phrases = append(phrases, phrase)
+		}
+	}
+	return phrases
+}
+
+func equivalent(phrases [][]string) []synonymRule {
+	if len(phrases) < 2 {
+		return nil
+	}
+	rules := make([]synonymRule, 0, len(phrases))
+	for i, phrase := range phrases {
+		to := make([][]string, 0, len(phrases) - 1)
+		to = append(to, phrases[:i]...)
+		to = append(to, phrases[i+1:]...)
+		rules = append(rules, synonymRule{from: phrase, to: to})
+	}
+	return rules
+}
+
+// synonymFilter expands phrases of the token stream. Rules are normalized like the stream
+// they run on: split by the standard tokenizer, lowercased and without stop words.
+type synonymFilter struct {
+	rules 		map[string][][]string
+	longest 	int
+	weight 		float64
+}
+
+func newSynonymFilter(lang string, res *resources) (tokenFilter, error) {
+	f := &synonymFilter{rules: map[string][][]string{}, weight: res.synonymWeight}
+	sw := languages[lang].stopWords
+	tokenize := standardTokenizer()
+	normalize := func(phrase []string) []string {
+		var words []string
+		for _, t := range tokenize(strings.Join(phrase, " ")) {
+			if word := strings.ToLower(t.Term); !sw.isStopWord(word) {
+				words = append(words, word)
+			}
+		}
+		return words
+	}
+	for _, rule := range res.synonyms {
+		from := normalize(rule.from)
+		if len(from) == 0 {
+			continue
+		}
+		key := strings.Join(from, " ")
+		for _, to := range rule.to {
+			if to := normalize(to); len(to) > 0 && strings.Join(to, " ") != key {
+				f.rules[key] = append(f.rules[key], to)
+			}
+		}
+		f.longest = max(f.longest, len(from))
+	}
+	return f.expand, nil
+}
+
+// expand adds the words of every synonym of the longest phrase starting at each token,
+// at the position of its first word and with the lower synonym weight. Words of a synonym
+// of several words are numbered, so the query matches them as a phrase.
+func (f *synonymFilter) expand(tokens []Token) []Token {
+	if len(f.rules) == 0 {
+		return tokens
+	}
+	out := make([]Token, 0, len(tokens))
+	for i, t := range tokens {
+		out = append(out, t)
+		if t.Derived() {
+			continue
+		}
+		words := []string{}
+		var match [][]string
+		pos := t.Pos
+		for _, next := range tokens[i:] {
+			if len(words) == f.longest {
+				break
+			}
+			if next.Derived() || next.Pos == pos && len(words) > 0 {
+				continue
+			}
+			if len(words) > 0 && next.Pos != pos + 1 {
+				break
+			}
+			pos = next.Pos
+			words = append(words, next.Term)
+			if to, ok := f.rules[strings.Join(words, " ")]; ok {
+				match = to
+			}
+		}
+		for _, phrase := range match {
+			for k, word := range phrase {
+				token := Token{Text: t.Text, Term: word, Pos: t.Pos, Weight: f.weight, kind: SYNONYM}
+				if len(phrase) > 1 {
+					token.phrase = k + 1
+				}
+				out = append(out, token)
+			}
+		}
+	}
+	return out
+}
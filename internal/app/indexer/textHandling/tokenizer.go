@@ -1,11 +1,12 @@
 package textHandling
 
 import (
-	"bytes"
+	"net"
 	"regexp"
 	"sort"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 type tokenType int
@@ -20,6 +21,17 @@ const (
 	EMAIL_ADDR
 	URL_ADDR
 	IP_V4_ADDR
+	SYNONYM
+	SHINGLE
+	IP_V6_ADDR
+	IDENTIFIER
+	VERSION
+	TECH_TERM
+	HASHTAG
+	MENTION
+	FILE_PATH
+	CJK
+	SUBWORD
 )
 
 type token struct {
@@ -34,10 +46,14 @@ type entityToken struct {
 	Priority int
 }
 
+// entityRule recognizes one kind of entity. When Regex has a group named "t" only the group
+// is the entity, the rest of the match is context. Check rejects matches the regex can't tell apart.
 type entityRule struct {
 	Regex 		*regexp.Regexp
 	TokenType 	tokenType
 	Priority 	int
+	group 		int
+	check 		func(string) bool
 }
 
 func newEntityRule(regex *regexp.Regexp, tokenType tokenType, priority int) *entityRule {
@@ -45,15 +61,39 @@ func newEntityRule(regex *regexp.Regexp, tokenType tokenType, priority int) *ent
 		Regex: regex,
 		TokenType: tokenType,
 		Priority: priority,
+		group: max(regex.SubexpIndex("t"), 0),
 	}
 }
 
+func (r *entityRule) withCheck(check func(string) bool) *entityRule {
+	r.check = check
+	return r
+}
+
 type tokenizer struct {
 	rules []*entityRule
 }
 
+func newTokenizer() *tokenizer {
+	return &tokenizer{
+		rules: []*entityRule{
+			newEntityRule(complieEmailRegex(), EMAIL_ADDR, 3),
+			newEntityRule(compileIPV4Regex(), IP_V4_ADDR, 2),
+			newEntityRule(compileIPV6Regex(), IP_V6_ADDR, 2).withCheck(isIPV6),
+			newEntityRule(complieURLRegex(), URL_ADDR, 3),
+			newEntityRule(compileFilePathRegex(), FILE_PATH, 2),
+			newEntityRule(compileVersionRegex(), VERSION, 1),
+			newEntityRule(compileTechTermRegex(), TECH_TERM, 1),
+			newEntityRule(compileHashtagRegex(), HASHTAG, 1),
+			newEntityRule(compileMentionRegex(), MENTION, 1),
+			newEntityRule(compileIdentifierRegex(), IDENTIFIER, 0).withCheck(isIdentifier),
+			newEntityRule(compileNumberRegex(), NUMBER, 0),
+		},
+	}
+}
+
 func complieEmailRegex() *regexp.Regexp {
-	return regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
+	return regexp.MustCompile(`\b[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}\b`)
 }
 
 func complieURLRegex() *regexp.Regexp {
@@ -65,7 +105,108 @@ func compileIPV4Regex() *regexp.Regexp {
 	return regexp.MustCompile(`\b` + octetRegex + `\.` + octetRegex + `\.` + octetRegex + `\.` + octetRegex + `\b`)
 }
 
+// compileIPV6Regex matches colon separated hex groups, isIPV6 sorts out times and the like.
+func compileIPV6Regex() *regexp.Regexp {
+	return regexp.MustCompile(`(?:^|[^\w:])(?P<t>(?:[0-9A-Fa-f]{0,4}:){2,7}[0-9A-Fa-f]{0,4})`)
+}
+
+func isIPV6(s string) bool {
+	ip := net.ParseIP(s)
+	return ip != nil && ip.To4() == nil
+}
+
+// compileFilePathRegex matches absolute unix and windows paths, paths relative to . .. or ~
+// and relative paths ending in a file extension, like src/main.go.
+func compileFilePathRegex() *regexp.Regexp {
+	return regexp.MustCompile(`(?:^|[^\w.~/\\:])(?P<t>(?:(?:~|\.{1,2})?/[\w.-]+(?:/[\w.-]+)*/?|[A-Za-z]:\\[\w.-]+(?:\\[\w.-]+)*|[\w-]+(?:/[\w.-]+)*/[\w-]+\.[A-Za-z0-9]{1,5}))`)
+}
+
+// compileVersionRegex matches semantic versions, with a leading v two parts are enough.
+func compileVersionRegex() *regexp.Regexp {
+	return regexp.MustCompile(`\b(?:[vV]\d+\.\d+(?:\.\d+)?|\d+\.\d+\.\d+)(?:-[0-9A-Za-z.-]+)?(?:\+[0-9A-Za-z.-]+)?\b`)
+}
+
+// compileTechTermRegex matches names with symbols a tokenizer would drop: c++, c#, node.js, .net.
+func compileTechTermRegex() *regexp.Regexp {
+	return regexp.MustCompile(`(?:^|[^\w.])(?P<t>[A-Za-z][A-Za-z0-9]*(?:\+\+|#)|[A-Za-z][\w-]*\.js\b|\.[Nn][Ee][Tt]\b)`)
+}
+
+func compileHashtagRegex() *regexp.Regexp {
+	return regexp.MustCompile(`(?:^|[^\w&#])(?P<t>#[A-Za-z_]\w*)`)
+}
+
+func compileMentionRegex() *regexp.Regexp {
+	return regexp.MustCompile(`(?:^|[^\w.@])(?P<t>@[A-Za-z_]\w*)`)
+}
+
+func compileIdentifierRegex() *regexp.Regexp {
+	return regexp.MustCompile(`\b[A-Za-z_][A-Za-z0-9_]*\b`)
+}
+
+// isIdentifier accepts snake_case and camelCase names, plain words are left to the fragment tokenizer.
+func isIdentifier(s string) bool {
+	if strings.Contains(s, "_") {
+		return len(splitIdentifier(s)) > 1
+	}
+	for i := 1; i < len(s); i++ {
+		if isLower(s[i-1]) && isUpper(s[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+func isLower(c byte) bool {
+	return 'a' <= c && c <= 'z'
+}
+
+func isUpper(c byte) bool {
+	return 'A' <= c && c <= 'Z'
+}
+
+// splitIdentifier breaks an identifier at underscores and case changes,
+// an uppercase run keeps all but its last letter: XMLHttpRequest is XML Http Request.
+func splitIdentifier(s string) []string {
+	var parts []string
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool { return r == '_' }) {
+		start := 0
+		for i := 1; i < len(part); i++ {
+			lowerToUpper := !isUpper(part[i-1]) && isUpper(part[i])
+			acronymEnd := isUpper(part[i-1]) && isUpper(part[i]) && i + 1 < len(part) && isLower(part[i+1])
+			if lowerToUpper || acronymEnd {
+				parts = append(parts, part[start:i])
+				start = i
+			}
+		}
+		parts = append(parts, part[start:])
+	}
+	return parts
+}
+
+// subTokens are the parts of an entity indexed next to it, so a query for any part finds it.
+func subTokens(t token) []string {
+	var parts []string
+	switch t.Type {
+	case IDENTIFIER:
+		parts = splitIdentifier(t.Value)
+	case HASHTAG, MENTION:
+		parts = splitIdentifier(t.Value[1:])
+		if len(parts) > 1 {
+			parts = append([]string{t.Value[1:]}, parts...)
+		}
+	case TECH_TERM, FILE_PATH:
+		parts = strings.FieldsFunc(t.Value, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	}
+	if len(parts) == 1 && parts[0] == t.Value {
+		return nil
+	}
+	return parts
+}
+
 func getRuneType(r rune) tokenType {
+	if isCJK(r) {
+		return CJK
+	}
 	if unicode.IsLetter(r) {
 		return WORD
 	}
@@ -81,15 +222,25 @@ func getRuneType(r rune) tokenType {
 	return UNKNOWN
 }
 
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
 func (o *tokenizer) entityTokenize(input string) []token {
 	var AllPotentialTokens []entityToken
 
 	for _, rule := range o.rules {
-		matches := rule.Regex.FindAllStringIndex(input, -1)
+		matches := rule.Regex.FindAllStringSubmatchIndex(input, -1)
 		for _, matchIndices := range matches {
-			start := matchIndices[0]
-			end := matchIndices[1]
+			start := matchIndices[2 * rule.group]
+			end := matchIndices[2 * rule.group + 1]
+			if start < 0 {
+				continue
+			}
 			value := input[start:end]
+			if rule.check != nil && !rule.check(value) {
+				continue
+			}
 
 			AllPotentialTokens = append(AllPotentialTokens, entityToken{
 				token: token{
@@ -103,6 +254,7 @@ func (o *tokenizer) entityTokenize(input string) []token {
 		}
 	}
 
+	// at one start position the higher priority wins, then the longer match
 	sort.SliceStable(AllPotentialTokens, func(i, j int) bool {
 		if AllPotentialTokens[i].startPos != AllPotentialTokens[j].startPos {
 			return AllPotentialTokens[i].startPos < AllPotentialTokens[j].startPos
@@ -110,7 +262,7 @@ func (o *tokenizer) entityTokenize(input string) []token {
 		if AllPotentialTokens[i].Priority != AllPotentialTokens[j].Priority {
 			return AllPotentialTokens[i].Priority > AllPotentialTokens[j].Priority
 		}
-		return AllPotentialTokens[i].startPos - AllPotentialTokens[i].endPos > AllPotentialTokens[j].startPos - AllPotentialTokens[j].endPos
+		return AllPotentialTokens[i].endPos > AllPotentialTokens[j].endPos
 	})
 
 	var selectedEntityTokens []token
@@ -141,64 +293,67 @@ func (o *tokenizer) entityTokenize(input string) []token {
 	return finalTokens
 }
 
+// fragmentTokenize splits text without entities into runs of one rune class, letters followed
+// by digits or the other way round make an ALPHANUMERIC token. CJK runs, which have no spaces
+// between words, are cut into overlapping bigrams. Positions are byte offsets in the whole input.
 func (o *tokenizer) fragmentTokenize(textFragment string, globalStartPos int) []token {
 	var tokens []token
-	var currentTokenBuffer bytes.Buffer
-	var currentTokenType tokenType = UNKNOWN
-	startPos := 0
-	textFragment = strings.ToLower(textFragment)
-	runes := []rune(textFragment)
-
-	
-	for i := range runes {
-		r := runes[i]
-		rType := getRuneType(r)
+	currentTokenType := UNKNOWN
+	start := -1
 
-		if currentTokenBuffer.Len() == 0 {
-			currentTokenBuffer.WriteRune(r)
-			currentTokenType = rType
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+		if currentTokenType == CJK {
+			tokens = append(tokens, cjkBigrams(textFragment[start:end], globalStartPos + start)...)
 		} else {
-			shouldCombineNow := false
-			if (currentTokenType == WORD && rType == NUMBER) ||
-				(currentTokenType == NUMBER && rType == WORD) {
-				shouldCombineNow = true
-			}
+			tokens = append(tokens, token{
+				Type:     currentTokenType,
+				Value:    textFragment[start:end],
+				startPos: globalStartPos + start,
+				endPos:   globalStartPos + end,
+			})
+		}
+		start = -1
+	}
 
-			isContinuingAlphanumeric := false
-			if currentTokenType == ALPHANUMERIC && (rType == WORD || rType == NUMBER) {
-				isContinuingAlphanumeric = true
-			}
+	for i, r := range textFragment {
+		rType := getRuneType(r)
+
+		if start >= 0 {
+			shouldCombineNow := (currentTokenType == WORD && rType == NUMBER) ||
+				(currentTokenType == NUMBER && rType == WORD)
+			isContinuingAlphanumeric := currentTokenType == ALPHANUMERIC && (rType == WORD || rType == NUMBER)
 
 			if rType == currentTokenType || shouldCombineNow || isContinuingAlphanumeric {
-				currentTokenBuffer.WriteRune(r)
 				if shouldCombineNow {
 					currentTokenType = ALPHANUMERIC
 				}
-			} else {
-				if currentTokenBuffer.Len() > 0 {
-					tokens = append(tokens, token{
-						Value: currentTokenBuffer.String(),
-						Type:  currentTokenType,
-						startPos: globalStartPos + startPos,
-						endPos:   globalStartPos + startPos + currentTokenBuffer.Len(),
-					})
-				}
-
-				currentTokenBuffer.Reset()
-				currentTokenBuffer.WriteRune(r)
-				currentTokenType = rType
+				continue
 			}
+			flush(i)
 		}
+		start = i
+		currentTokenType = rType
 	}
+	flush(len(textFragment))
 
-	if currentTokenBuffer.Len() > 0 {
-		tokens = append(tokens, token{
-			Type:     currentTokenType,
-			Value:    currentTokenBuffer.String(),
-			startPos: startPos,
-			endPos:   startPos + currentTokenBuffer.Len(),
-		})
-	}
+	return tokens
+}
 
+func cjkBigrams(run string, offset int) []token {
+	if utf8.RuneCountInString(run) == 1 {
+		return []token{{Type: CJK, Value: run, startPos: offset, endPos: offset + len(run)}}
+	}
+	var tokens []token
+	prev := 0
+	for i, r := range run {
+		if i > 0 {
+			end := i + utf8.RuneLen(r)
+			tokens = append(tokens, token{Type: CJK, Value: run[prev:end], startPos: offset + prev, endPos: offset + end})
+		}
+		prev = i
+	}
 	return tokens
-}
\ No newline at end of file
+}
@@ -0,0 +1,112 @@
+package textHandling
+
+import (
+	"reflect"
+	"testing"
+)
+
+type tokenCase struct {
+	typ 	tokenType
+	value 	string
+}
+
+// entities tokenizes input and keeps what isn't whitespace.
+func entities(input string) []tokenCase {
+	var out []tokenCase
+	for _, t := range newTokenizer().entityTokenize(input) {
+		if t.Type != WHITESPACE {
+			out = append(out, tokenCase{t.Type, t.Value})
+		}
+	}
+	return out
+}
+
+func TestEntityTokenize(t *testing.T) {
+	tests := []struct {
+		name 	string
+		input 	string
+		want 	[]tokenCase
+	}{
+		{"snake case", "call snake_case now", []tokenCase{{WORD, "call"}, {IDENTIFIER, "snake_case"}, {WORD, "now"}}},
+		{"camel case", "camelCase", []tokenCase{{IDENTIFIER, "camelCase"}}},
+		{"plain words stay words", "Hello world", []tokenCase{{WORD, "Hello"}, {WORD, "world"}}},
+		{"version with v", "go v1.24.3 released", []tokenCase{{WORD, "go"}, {VERSION, "v1.24.3"}, {WORD, "released"}}},
+		{"semver with pre-release", "1.2.3-beta.1", []tokenCase{{VERSION, "1.2.3-beta.1"}}},
+		{"c++", "C++ and C#", []tokenCase{{TECH_TERM, "C++"}, {WORD, "and"}, {TECH_TERM, "C#"}}},
+		{"node.js", "node.js", []tokenCase{{TECH_TERM, "node.js"}}},
+		{"hashtag", "#golang rocks", []tokenCase{{HASHTAG, "#golang"}, {WORD, "rocks"}}},
+		{"mention", "ask @box1bs", []tokenCase{{WORD, "ask"}, {MENTION, "@box1bs"}}},
+		{"email is no mention", "me@example.com", []tokenCase{{EMAIL_ADDR, "me@example.com"}}},
+		{"absolute path", "open /usr/local/bin", []tokenCase{{WORD, "open"}, {FILE_PATH, "/usr/local/bin"}}},
+		{"relative path", "src/main.go", []tokenCase{{FILE_PATH, "src/main.go"}}},
+		{"windows path", `C:\Windows\System32`, []tokenCase{{FILE_PATH, `C:\Windows\System32`}}},
+		{"ipv6", "ping 2001:db8::1", []tokenCase{{WORD, "ping"}, {IP_V6_ADDR, "2001:db8::1"}}},
+		{"time is no ipv6", "12:30", []tokenCase{{NUMBER, "12"}, {SYMBOL, ":"}, {NUMBER, "30"}}},
+		{"ipv4", "10.0.0.1", []tokenCase{{IP_V4_ADDR, "10.0.0.1"}}},
+		{"cjk bigrams", "東京大学", []tokenCase{{CJK, "東京"}, {CJK, "京大"}, {CJK, "大学"}}},
+		{"single cjk rune", "猫", []tokenCase{{CJK, "猫"}}},
+		{"cjk between words", "go 言語 tips", []tokenCase{{WORD, "go"}, {CJK, "言語"}, {WORD, "tips"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := entities(tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("entityTokenize(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEntityTokenizePositions(t *testing.T) {
+	input := "東京 v1.2"
+	got := newTokenizer().entityTokenize(input)
+	for _, tok := range got {
+		if input[tok.startPos:tok.endPos] != tok.Value {
+			t.Fatalf("token %q spans %q", tok.Value, input[tok.startPos:tok.endPos])
+		}
+	}
+}
+
+func TestSubTokens(t *testing.T) {
+	tests := []struct {
+		tok 	token
+		want 	[]string
+	}{
+		{token{Type: IDENTIFIER, Value: "snake_case"}, []string{"snake", "case"}},
+		{token{Type: IDENTIFIER, Value: "camelCase"}, []string{"camel", "Case"}},
+		{token{Type: IDENTIFIER, Value: "XMLHttpRequest"}, []string{"XML", "Http", "Request"}},
+		{token{Type: HASHTAG, Value: "#golangTips"}, []string{"golangTips", "golang", "Tips"}},
+		{token{Type: HASHTAG, Value: "#golang"}, []string{"golang"}},
+		{token{Type: MENTION, Value: "@box1bs"}, []string{"box1bs"}},
+		{token{Type: TECH_TERM, Value: "node.js"}, []string{"node", "js"}},
+		{token{Type: TECH_TERM, Value: "C++"}, []string{"C"}},
+		{token{Type: FILE_PATH, Value: "src/main.go"}, []string{"src", "main", "go"}},
+		{token{Type: VERSION, Value: "v1.24.3"}, nil},
+	}
+	for _, tt := range tests {
+		if got := subTokens(tt.tok); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("subTokens(%q) = %q, want %q", tt.tok.Value, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeIndexesSubTokens(t *testing.T) {
+	tests := []struct {
+		input 	string
+		want 	[]string
+	}{
+		{"snake_case", []string{"snake_case", "snake", "case"}},
+		{"#golang", []string{"#golang", "golang"}},
+	}
+	a := DefaultAnalyzers().Get(DefaultLanguage)
+	for _, tt := range tests {
+		got := map[string]bool{}
+		for _, term := range a.Terms(tt.input) {
+			got[term] = true
+		}
+		for _, term := range tt.want {
+			if !got[term] {
+				t.Errorf("Terms(%q) = %v, missing %q", tt.input, a.Terms(tt.input), term)
+			}
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package textHandling
+
+import (
+	"net"
+	"regexp"
+	"strings"
+)
+
+// TypedTermPrefix starts the terms of emails, urls, ip addresses and numbers. No word
+// starts with it, so "$num:42" can't collide with a word and "42" is found only as a number.
+const TypedTermPrefix = "$"
+
+// typedFields are the field names of typed terms, a query clause like ip:10.0.0.1 searches
+// its value as a term of that type only.
+var typedFields = map[tokenType]string{
+	EMAIL_ADDR: "email",
+	URL_ADDR: 	"url",
+	IP_V4_ADDR: "ip",
+	IP_V6_ADDR: "ip",
+	NUMBER: 	"num",
+}
+
+var fieldClause = regexp.MustCompile(`(?i)(?:^|\s)(email|url|ip|num):(\S+)`)
+
+// typedTerm is the term a typed token is indexed under, values are normalized so that
+// different spellings of one address or number meet: ::0001 is ::1 and 1,000.50 is 1000.5.
+func typedTerm(kind tokenType, value string) string {
+	field, ok := typedFields[kind]
+	if !ok {
+		return value
+	}
+	switch kind {
+	case IP_V4_ADDR, IP_V6_ADDR:
+		if ip := net.ParseIP(value); ip != nil {
+			value = ip.String()
+		}
+	case NUMBER:
+		value = normalizeNumber(value)
+	default:
+		value = strings.ToLower(value)
+	}
+	return TypedTermPrefix + field + ":" + value
+}
+
+// Typed reports whether the token is an email, url, ip address or number term.
+func (t Token) Typed() bool {
+	return strings.HasPrefix(t.Term, TypedTermPrefix)
+}
+
+func normalizeNumber(value string) string {
+	value = strings.ReplaceAll(value, ",", "")
+	whole, frac, _ := strings.Cut(value, ".")
+	whole = strings.TrimLeft(whole, "0")
+	if whole == "" {
+		whole = "0"
+	}
+	if frac = strings.TrimRight(frac, "0"); frac != "" {
+		return whole + "." + frac
+	}
+	return whole
+}
+
+// fieldTokens cuts the field clauses out of a query and returns the rest of the text
+// together with the typed tokens of the clauses.
+func fieldTokens(text string) (string, []Token) {
+	var tokens []Token
+	matches := fieldClause.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return text, nil
+	}
+	rest := strings.Builder{}
+	last := 0
+	for _, m := range matches {
+		field, value := strings.ToLower(text[m[2]:m[3]]), text[m[4]:m[5]]
+		kind := fieldKind(field, value)
+		tokens = append(tokens, Token{Text: value, Term: typedTerm(kind, value), Weight: 1, kind: kind})
+		rest.WriteString(text[last:m[2]])
+		last = m[1]
+	}
+	rest.WriteString(text[last:])
+	return rest.String(), tokens
+}
+
+func fieldKind(field, value string) tokenType {
+	switch field {
+	case "email":
+		return EMAIL_ADDR
+	case "url":
+		return URL_ADDR
+	case "ip":
+		if ip := net.ParseIP(value); ip != nil && ip.To4() != nil {
+			return IP_V4_ADDR
+		}
+		return IP_V6_ADDR
+	}
+	return NUMBER
+}
+
+// compileNumberRegex matches decimals and numbers with thousands separators, plain digit runs
+// come from the fragment tokenizer.
+func compileNumberRegex() *regexp.Regexp {
+	return regexp.MustCompile(`\b(?:\d{1,3}(?:,\d{3})+(?:\.\d+)?|\d+\.\d+)\b`)
+}
@@ -0,0 +1,195 @@
+package indexer
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+
+	"github.com/box1bs/monocle/configs"
+	"github.com/box1bs/monocle/internal/model"
+	"github.com/box1bs/monocle/pkg/hnsw"
+)
+
+// vectorIndex keeps an in-memory graph of document chunk embeddings mirrored to the repository.
+// Every chunk is a node labeled with the document id and the chunk index, docs holds the live
+// node ids of every document by chunk.
+type vectorIndex struct {
+	mu 			sync.Mutex
+	graph 		*hnsw.Index
+	docs 		map[[32]byte][]uint64
+	candidates 	int
+}
+
+// LoadVectorIndex reads the stored graph, building it from the indexed documents when
+// the index predates it or the stored graph is inconsistent.
+func (idx *indexer) LoadVectorIndex(cfg configs.VectorIndexConfig) error {
+	if cfg.Disabled {
+		return nil
+	}
+	newGraph := func() *hnsw.Index {
+		return hnsw.New(hnsw.Config{M: cfg.M, EfConstruction: cfg.EfConstruction, EfSearch: cfg.EfSearch})
+	}
+	vi := &vectorIndex{graph: newGraph(), docs: map[[32]byte][]uint64{}, candidates: cfg.Candidates}
+	if vi.candidates <= 0 {
+		vi.candidates = 100
+	}
+
+	meta, err := idx.repository.LoadVectorIndex(func(id uint64, data []byte) error {
+		return vi.graph.DecodeNode(id, data)
+	})
+	if err == nil && meta != nil {
+		if err = vi.graph.DecodeMeta(meta); err == nil {
+			err = vi.graph.Validate()
+		}
+	}
+	if err != nil {
+		idx.logger.Write(fmt.Sprintf("vector index is unreadable, rebuilding: %v", err))
+		if err := idx.repository.ClearVectorIndex(); err != nil {
+			return err
+		}
+		vi.graph, meta = newGraph(), nil
+	}
+
+	if meta != nil {
+		for id, label := range vi.graph.Labels() {
+			doc, chunk := [32]byte(label[:32]), int(binary.BigEndian.Uint16(label[32:]))
+			nodes := vi.docs[doc]
+			if len(nodes) <= chunk {
+				nodes = append(nodes, make([]uint64, chunk + 1 - len(nodes))...)
+			}
+			nodes[chunk] = id
+			vi.docs[doc] = nodes
+		}
+		idx.vectors = vi
+		return nil
+	}
+
+	docs, err := idx.repository.GetAllDocuments()
+	if err != nil {
+		return err
+	}
+	idx.vectors = vi
+	for _, doc := range docs {
+		if doc.WordVec, err = idx.repository.GetEmbeddings(doc.Id); err != nil {
+			return err
+		}
+		if err := idx.addVectors(doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (idx *indexer) addVectors(doc *model.Document) error {
+	vi := idx.vectors
+	if vi == nil || len(doc.WordVec) == 0 {
+		return nil
+	}
+
+	// inserts are serialized so that stored metadata never refers to unsaved nodes
+	vi.mu.Lock()
+	defer vi.mu.Unlock()
+	if old, ok := vi.docs[doc.Id]; ok && vi.same(old, doc.WordVec) {
+		return nil
+	}
+
+	// a page crawled again replaces its vectors, the old nodes become tombstones
+	changed := vi.tombstone(doc.Id)
+	ids := make([]uint64, 0, len(doc.WordVec))
+	for i, vec := range doc.WordVec {
+		label := binary.BigEndian.AppendUint16(append([]byte(nil), doc.Id[:]...), uint16(i))
+		id, touched, err := vi.graph.Insert(label, vec)
+		if err != nil {
+			return fmt.Errorf("document %x chunk %d: %w", doc.Id, i, err)
+		}
+		ids = append(ids, id)
+		for _, t := range touched {
+			changed[t] = struct{}{}
+		}
+	}
+	if err := idx.saveNodes(changed); err != nil {
+		return err
+	}
+	vi.docs[doc.Id] = ids
+	return nil
+}
+
+// removeVectors turns the nodes of a document into tombstones.
+func (idx *indexer) removeVectors(docID [32]byte) error {
+	vi := idx.vectors
+	if vi == nil {
+		return nil
+	}
+	vi.mu.Lock()
+	defer vi.mu.Unlock()
+	return idx.saveNodes(vi.tombstone(docID))
+}
+
+// tombstone deletes the nodes of a document from the graph and returns their ids, vi.mu must be held.
+func (vi *vectorIndex) tombstone(docID [32]byte) map[uint64]struct{} {
+	changed := map[uint64]struct{}{}
+	for _, id := range vi.docs[docID] {
+		if vi.graph.Delete(id) {
+			changed[id] = struct{}{}
+		}
+	}
+	delete(vi.docs, docID)
+	return changed
+}
+
+// same reports whether the nodes hold exactly the chunk vectors, vi.mu must be held.
+func (vi *vectorIndex) same(ids []uint64, vecs [][]float64) bool {
+	if len(ids) != len(vecs) {
+		return false
+	}
+	for i, id := range ids {
+		if !vi.graph.Equal(id, vecs[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (idx *indexer) saveNodes(changed map[uint64]struct{}) error {
+	if len(changed) == 0 {
+		return nil
+	}
+	vi := idx.vectors
+	nodes := make(map[uint64][]byte, len(changed))
+	for id := range changed {
+		nodes[id] = vi.graph.EncodeNode(id)
+	}
+	return idx.repository.SaveVectorNodes(vi.graph.EncodeMeta(), nodes)
+}
+
+// SearchVectors returns up to k documents with the most similar chunk, best first.
+// k <= 0 uses the configured number of candidates.
+func (idx *indexer) SearchVectors(vec []float64, k int) ([]model.VectorMatch, error) {
+	vi := idx.vectors
+	if vi == nil {
+		return nil, fmt.Errorf("vector index is not loaded")
+	}
+	if k <= 0 {
+		k = vi.candidates
+	}
+
+	// documents have several chunks, so more nodes than documents are requested
+	results, err := vi.graph.Search(vec, 4 * k)
+	if err != nil {
+		return nil, err
+	}
+	matches := make([]model.VectorMatch, 0, k)
+	seen := map[[32]byte]struct{}{}
+	for _, r := range results {
+		id := [32]byte(r.Label[:32])
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		matches = append(matches, model.VectorMatch{DocID: id, Chunk: int(binary.BigEndian.Uint16(r.Label[32:])), Similarity: r.Similarity})
+		if len(matches) == k {
+			break
+		}
+	}
+	return matches, nil
+}
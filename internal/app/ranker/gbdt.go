@@ -0,0 +1,62 @@
+package ranker
+
+import "fmt"
+
+type treeNode struct {
+	Feature 	int 		`json:"feature"`
+	Threshold 	float64 	`json:"threshold"`
+	Left 		int 		`json:"left"`
+	Right 		int 		`json:"right"`
+	Leaf 		*float64 	`json:"leaf,omitempty"`
+}
+
+// nodes[0] is the root, a node goes left when feature value < threshold
+type tree struct {
+	Nodes []treeNode `json:"nodes"`
+}
+
+type treeEnsemble struct {
+	baseScore 		float64
+	learningRate 	float64
+	trees 			[]tree
+	bind 			[]int
+}
+
+func (t *tree) validate(featureCount int) error {
+	if len(t.Nodes) == 0 {
+		return fmt.Errorf("empty tree")
+	}
+	for i, n := range t.Nodes {
+		if n.Leaf != nil {
+			continue
+		}
+		if n.Feature < 0 || n.Feature >= featureCount {
+			return fmt.Errorf("node %d: feature index %d out of range", i, n.Feature)
+		}
+		if n.Left <= i || n.Right <= i || n.Left >= len(t.Nodes) || n.Right >= len(t.Nodes) {
+			return fmt.Errorf("node %d: invalid children %d, %d", i, n.Left, n.Right)
+		}
+	}
+	return nil
+}
+
+func (t *tree) predict(features []float64, bind []int) float64 {
+	i := 0
+	for t.Nodes[i].Leaf == nil {
+		n := t.Nodes[i]
+		if features[bind[n.Feature]] < n.Threshold {
+			i = n.Left
+		} else {
+			i = n.Right
+		}
+	}
+	return *t.Nodes[i].Leaf
+}
+
+func (e *treeEnsemble) Predict(features []float64) float64 {
+	score := e.baseScore
+	for i := range e.trees {
+		score += e.learningRate * e.trees[i].predict(features, e.bind)
+	}
+	return score
+}
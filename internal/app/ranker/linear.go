@@ -0,0 +1,38 @@
+package ranker
+
+import (
+	"encoding/json"
+	"os"
+)
+
+type LinearModel struct {
+	Features 	[]string
+	Weights 	[]float64
+	Bias 		float64
+	bind 		[]int
+}
+
+func (m *LinearModel) Predict(features []float64) float64 {
+	score := m.Bias
+	for i, w := range m.Weights {
+		score += w * features[m.bind[i]]
+	}
+	return score
+}
+
+func (m *LinearModel) Save(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	enc := json.NewEncoder(file)
+	enc.SetIndent("", "    ")
+	return enc.Encode(modelFile{
+		Type: 		"linear",
+		Features: 	m.Features,
+		Bias: 		m.Bias,
+		Weights: 	m.Weights,
+	})
+}
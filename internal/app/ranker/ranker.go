@@ -0,0 +1,78 @@
+package ranker
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+type Model interface {
+	Predict([]float64) float64
+}
+
+type modelFile struct {
+	Type 			string 		`json:"type"`
+	Features 		[]string 	`json:"features"`
+	Bias 			float64 	`json:"bias,omitempty"`
+	Weights 		[]float64 	`json:"weights,omitempty"`
+	BaseScore 		float64 	`json:"base_score,omitempty"`
+	LearningRate 	float64 	`json:"learning_rate,omitempty"`
+	Trees 			[]tree 		`json:"trees,omitempty"`
+}
+
+// Load reads a model description and binds its feature names to positions in
+// the vector produced by the searcher, so the file does not depend on feature order.
+func Load(path string, featureNames []string) (Model, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var mf modelFile
+	if err := json.NewDecoder(file).Decode(&mf); err != nil {
+		return nil, err
+	}
+
+	bind, err := bindFeatures(mf.Features, featureNames)
+	if err != nil {
+		return nil, err
+	}
+
+	switch mf.Type {
+	case "linear":
+		if len(mf.Weights) != len(mf.Features) {
+			return nil, fmt.Errorf("linear model has %d weights for %d features", len(mf.Weights), len(mf.Features))
+		}
+		return &LinearModel{Features: mf.Features, Weights: mf.Weights, Bias: mf.Bias, bind: bind}, nil
+	case "gbdt":
+		for i := range mf.Trees {
+			if err := mf.Trees[i].validate(len(mf.Features)); err != nil {
+				return nil, fmt.Errorf("tree %d: %w", i, err)
+			}
+		}
+		lr := mf.LearningRate
+		if lr == 0 {
+			lr = 1
+		}
+		return &treeEnsemble{baseScore: mf.BaseScore, learningRate: lr, trees: mf.Trees, bind: bind}, nil
+	default:
+		return nil, fmt.Errorf("unknown model type: %q", mf.Type)
+	}
+}
+
+func bindFeatures(modelFeatures, featureNames []string) ([]int, error) {
+	index := make(map[string]int, len(featureNames))
+	for i, name := range featureNames {
+		index[name] = i
+	}
+	bind := make([]int, len(modelFeatures))
+	for i, name := range modelFeatures {
+		pos, ok := index[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown feature in model: %q", name)
+		}
+		bind[i] = pos
+	}
+	return bind, nil
+}
@@ -0,0 +1,214 @@
+package ranker
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testFeatures = []string{"tf_idf", "bm25", "cosine"}
+
+func writeModel(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "model.json")
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadLinearBindsFeaturesByName(t *testing.T) {
+	path := writeModel(t, `{"type": "linear", "features": ["cosine", "tf_idf"], "bias": 0.5, "weights": [2, -1]}`)
+	m, err := Load(path, testFeatures)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 0.5 + 2 * cosine - 1 * tf_idf
+	if got := m.Predict([]float64{3, 100, 4}); got != 5.5 {
+		t.Fatalf("Predict = %v, want 5.5", got)
+	}
+}
+
+func TestLoadGBDT(t *testing.T) {
+	// one stump on bm25 (model feature 0): < 1 scores -1, otherwise 2
+	path := writeModel(t, `{
+		"type": "gbdt",
+		"features": ["bm25"],
+		"base_score": 0.25,
+		"learning_rate": 0.5,
+		"trees": [
+			{"nodes": [
+				{"feature": 0, "threshold": 1, "left": 1, "right": 2},
+				{"leaf": -1},
+				{"leaf": 2}
+			]},
+			{"nodes": [{"leaf": 1}]}
+		]
+	}`)
+	m, err := Load(path, testFeatures)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		bm25 	float64
+		want 	float64
+	}{
+		{0.5, 0.25 + 0.5 * -1 + 0.5 * 1},
+		{1, 0.25 + 0.5 * 2 + 0.5 * 1},
+		{7, 0.25 + 0.5 * 2 + 0.5 * 1},
+	}
+	for _, tt := range tests {
+		if got := m.Predict([]float64{0, tt.bm25, 0}); got != tt.want {
+			t.Errorf("Predict(bm25=%v) = %v, want %v", tt.bm25, got, tt.want)
+		}
+	}
+}
+
+func TestLoadGBDTDefaultLearningRate(t *testing.T) {
+	path := writeModel(t, `{"type": "gbdt", "features": ["tf_idf"], "trees": [{"nodes": [{"leaf": 3}]}]}`)
+	m, err := Load(path, testFeatures)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Predict([]float64{0, 0, 0}); got != 3 {
+		t.Fatalf("Predict = %v, want 3", got)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name 	string
+		body 	string
+		want 	string
+	}{
+		{"unknown type", `{"type": "forest", "features": []}`, "unknown model type"},
+		{"unknown feature", `{"type": "linear", "features": ["pagerank"], "weights": [1]}`, "unknown feature"},
+		{"weight count", `{"type": "linear", "features": ["bm25"], "weights": [1, 2]}`, "2 weights for 1 features"},
+		{"empty tree", `{"type": "gbdt", "features": ["bm25"], "trees": [{"nodes": []}]}`, "empty tree"},
+		{"feature out of range", `{"type": "gbdt", "features": ["bm25"], "trees": [{"nodes": [
+			{"feature": 1, "threshold": 0, "left": 1, "right": 2}, {"leaf": 0}, {"leaf": 1}]}]}`, "out of range"},
+		{"backward child", `{"type": "gbdt", "features": ["bm25"], "trees": [{"nodes": [
+			{"leaf": 0}, {"feature": 0, "threshold": 0, "left": 0, "right": 2}, {"leaf": 1}]}]}`, "invalid children"},
+		{"malformed", `{"type": `, "unexpected EOF"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeModel(t, tt.body), testFeatures)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Load error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinearSaveLoad(t *testing.T) {
+	m := &LinearModel{Features: []string{"bm25", "cosine"}, Weights: []float64{0.5, 2}, Bias: -1, bind: []int{1, 2}}
+	path := filepath.Join(t.TempDir(), "linear.json")
+	if err := m.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(path, testFeatures)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := []float64{9, 4, 3}
+	if got, want := loaded.Predict(x), m.Predict(x); got != want {
+		t.Fatalf("loaded Predict = %v, want %v", got, want)
+	}
+}
+
+func TestTrainLinearRecoversWeights(t *testing.T) {
+	// label = 1 + 2a - 3b, c is noise free but irrelevant
+	var samples []Sample
+	for i := range 50 {
+		a, b, c := float64(i % 7), float64(i % 5) / 2, float64(i % 3)
+		samples = append(samples, Sample{Features: []float64{a, b, c}, Label: 1 + 2 * a - 3 * b})
+	}
+	m, err := TrainLinear(testFeatures, samples, 1e-9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []float64{2, -3, 0}
+	for i, w := range m.Weights {
+		if math.Abs(w - want[i]) > 1e-6 {
+			t.Errorf("weight %s = %v, want %v", testFeatures[i], w, want[i])
+		}
+	}
+	if math.Abs(m.Bias - 1) > 1e-6 {
+		t.Errorf("bias = %v, want 1", m.Bias)
+	}
+	if got := m.Predict([]float64{2, 1, 9}); math.Abs(got - 2) > 1e-6 {
+		t.Errorf("Predict = %v, want 2", got)
+	}
+}
+
+func TestTrainLinearConstantFeature(t *testing.T) {
+	samples := []Sample{
+		{Features: []float64{1, 5, 0}, Label: 1},
+		{Features: []float64{2, 5, 0}, Label: 2},
+		{Features: []float64{3, 5, 0}, Label: 3},
+	}
+	m, err := TrainLinear(testFeatures, samples, 1e-9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Weights[1] != 0 || m.Weights[2] != 0 {
+		t.Fatalf("constant features got weights %v", m.Weights)
+	}
+}
+
+func TestTrainLinearEmpty(t *testing.T) {
+	if _, err := TrainLinear(testFeatures, nil, 1); err == nil {
+		t.Fatal("TrainLinear on no samples succeeded")
+	}
+}
+
+func TestSolve(t *testing.T) {
+	// 2x + y - z = 8, -3x - y + 2z = -11, -2x + y + 2z = -3 has x = 2, y = 3, z = -1,
+	// the first column needs a pivot swap
+	a := [][]float64{
+		{2, 1, -1, 8},
+		{-3, -1, 2, -11},
+		{-2, 1, 2, -3},
+	}
+	w, err := solve(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []float64{2, 3, -1}
+	for i := range want {
+		if math.Abs(w[i] - want[i]) > 1e-9 {
+			t.Fatalf("solve = %v, want %v", w, want)
+		}
+	}
+}
+
+func TestSolveSingular(t *testing.T) {
+	// the second row is twice the first
+	a := [][]float64{
+		{1, 2, 3},
+		{2, 4, 6},
+	}
+	if _, err := solve(a); err == nil {
+		t.Fatal("solve on a singular system succeeded")
+	}
+}
+
+func TestTrainLinearSingularWithoutRidge(t *testing.T) {
+	samples := []Sample{
+		{Features: []float64{1, 1, 0}, Label: 1},
+		{Features: []float64{2, 2, 0}, Label: 2},
+		{Features: []float64{3, 3, 0}, Label: 3},
+	}
+	if _, err := TrainLinear(testFeatures, samples, 0); err == nil {
+		t.Fatal("TrainLinear on duplicate features without ridge succeeded")
+	}
+	if _, err := TrainLinear(testFeatures, samples, 1); err != nil {
+		t.Fatalf("TrainLinear with ridge: %v", err)
+	}
+	if _, err := TrainLinear(testFeatures, samples, -1); err == nil {
+		t.Fatal("TrainLinear with a negative penalty succeeded")
+	}
+}
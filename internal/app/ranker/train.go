@@ -0,0 +1,127 @@
+package ranker
+
+import (
+	"errors"
+	"math"
+)
+
+type Sample struct {
+	Features 	[]float64
+	Label 		float64
+}
+
+// TrainLinear fits a pointwise ridge regression of the relevance grade on the features.
+// Features are standardized before solving and the weights are mapped back to the raw scale.
+// lambda is the ridge penalty added to the diagonal; with lambda > 0 constant and collinear
+// features stay solvable and get shrunk towards zero, with lambda 0 they make the fit fail.
+func TrainLinear(featureNames []string, samples []Sample, lambda float64) (*LinearModel, error) {
+	n := len(featureNames)
+	if len(samples) == 0 {
+		return nil, errors.New("empty training set")
+	}
+	if lambda < 0 {
+		return nil, errors.New("negative ridge penalty")
+	}
+
+	mean := make([]float64, n)
+	std := make([]float64, n)
+	labelMean := 0.0
+	for _, s := range samples {
+		for j := range n {
+			mean[j] += s.Features[j]
+		}
+		labelMean += s.Label
+	}
+	count := float64(len(samples))
+	for j := range n {
+		mean[j] /= count
+	}
+	labelMean /= count
+	for _, s := range samples {
+		for j := range n {
+			d := s.Features[j] - mean[j]
+			std[j] += d * d
+		}
+	}
+	for j := range n {
+		std[j] = math.Sqrt(std[j] / count)
+		if std[j] == 0 {
+			std[j] = 1
+		}
+	}
+
+	a := make([][]float64, n)
+	for i := range a {
+		a[i] = make([]float64, n + 1)
+		a[i][i] = lambda
+	}
+	x := make([]float64, n)
+	for _, s := range samples {
+		for j := range n {
+			x[j] = (s.Features[j] - mean[j]) / std[j]
+		}
+		y := s.Label - labelMean
+		for i := range n {
+			for j := range n {
+				a[i][j] += x[i] * x[j]
+			}
+			a[i][n] += x[i] * y
+		}
+	}
+
+	w, err := solve(a)
+	if err != nil {
+		return nil, err
+	}
+
+	bias := labelMean
+	for j := range n {
+		w[j] /= std[j]
+		bias -= w[j] * mean[j]
+	}
+
+	bind := make([]int, n)
+	for i := range bind {
+		bind[i] = i
+	}
+	return &LinearModel{Features: featureNames, Weights: w, Bias: bias, bind: bind}, nil
+}
+
+var errSingular = errors.New("singular training matrix")
+
+// solve runs gaussian elimination with partial pivoting on an augmented matrix.
+// A column without a usable pivot means the system is singular and is reported as an error.
+func solve(a [][]float64) ([]float64, error) {
+	n := len(a)
+	for col := range n {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(a[pivot][col]) < 1e-12 {
+			return nil, errSingular
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		for row := col + 1; row < n; row++ {
+			f := a[row][col] / a[col][col]
+			for k := col; k <= n; k++ {
+				a[row][k] -= f * a[col][k]
+			}
+		}
+	}
+
+	w := make([]float64, n)
+	for i := n - 1; i >= 0; i-- {
+		sum := a[i][n]
+		for j := i + 1; j < n; j++ {
+			sum -= a[i][j] * w[j]
+		}
+		w[i] = sum / a[i][i]
+		if math.IsNaN(w[i]) || math.IsInf(w[i], 0) {
+			return nil, errSingular
+		}
+	}
+	return w, nil
+}
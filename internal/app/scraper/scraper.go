@@ -46,7 +46,7 @@ type webScraper struct {
 	globalCtx		context.Context
 	pageRank 		map[string]int
 	write 			func(string)
-	vectorize		func(string, context.Context) ([][]float64, error)
+	embed			func(context.Context, *model.Document, []model.Passage) error
 }
 
 type ConfigData struct {
@@ -60,7 +60,7 @@ type ConfigData struct {
 
 const sitemap = "sitemap.xml"
 
-func NewScraper(mp *sync.Map, cfg *ConfigData, wp workerPool, idx indexer, c context.Context, pr map[string]int, write func(string), vectorize func(string, context.Context) ([][]float64, error)) *webScraper {
+func NewScraper(mp *sync.Map, cfg *ConfigData, wp workerPool, idx indexer, c context.Context, pr map[string]int, write func(string), embed func(context.Context, *model.Document, []model.Passage) error) *webScraper {
 	return &webScraper{
 		client: &http.Client{
 			Timeout: 5 * time.Second,
@@ -79,7 +79,7 @@ func NewScraper(mp *sync.Map, cfg *ConfigData, wp workerPool, idx indexer, c con
 		globalCtx:		c,
 		pageRank: 		pr,
 		write: 			write,
-		vectorize:		vectorize,
+		embed:			embed,
 	}
 }
 
@@ -176,11 +176,6 @@ func (ws *webScraper) ScrapeWithContext(ctx context.Context, currentURL string,
 		return
 	}
 
-	fullText := strings.Builder{}
-	for _, passage := range passages {
-		fullText.WriteString(passage.Text)
-	}
-
 	linkFilled := make(chan struct{})
 	go func() {
 		defer close(linkFilled)
@@ -196,8 +191,7 @@ func (ws *webScraper) ScrapeWithContext(ctx context.Context, currentURL string,
 	c, cancel = context.WithTimeout(ctx, time.Second * 40)
 	defer cancel()
 	
-	document.WordVec, err = ws.vectorize(fullText.String(), c)
-	if err != nil {
+	if err := ws.embed(c, document, passages); err != nil {
 		ws.write(fmt.Sprintf("error vectorizing page: %s with error %v\n", currentURL, err))
 		return
 	}
@@ -380,6 +374,18 @@ func (ws *webScraper) parseHTMLStream(ctx context.Context, htmlContent, baseURL
 	return
 }
 
+// ParseHTML extracts typed passages from a page without following its links,
+// used to index local files.
+func ParseHTML(ctx context.Context, htmlContent, baseURL string, write func(string)) []model.Passage {
+	ws := &webScraper{
+		visited: 	&sync.Map{},
+		cfg: 		&ConfigData{},
+		write: 		write,
+	}
+	_, passages := ws.parseHTMLStream(ctx, htmlContent, baseURL, nil)
+	return passages
+}
+
 func (ws *webScraper) getHTML(ctx context.Context, URL string) (string, error) {
     req, err := http.NewRequestWithContext(ctx, "GET", URL, nil)
     if err != nil {
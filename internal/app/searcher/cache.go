@@ -0,0 +1,96 @@
+package searcher
+
+import (
+	"github.com/box1bs/monocle/internal/model"
+)
+
+const (
+	// resultCacheSize is the number of queries whose rankings are kept.
+	resultCacheSize = 1024
+	// cachedHits is the depth a ranking is cached to, deeper requests are ranked again.
+	cachedHits = 200
+)
+
+// resultKey is a normalized query with every parameter that changes its ranking.
+type resultKey struct {
+	query 			string
+	quorum 			float64
+	pipeline 		string
+	retrieval 		string
+	fusion 			string
+	rrfK 			int
+	vectorWeight 	float64
+	language 		string
+}
+
+func newResultKey(query string, params Params) resultKey {
+	return resultKey{
+		query: 			normalizeQuery(query),
+		quorum: 		params.Quorum,
+		pipeline: 		params.Pipeline,
+		retrieval: 		params.Retrieval,
+		fusion: 		params.Fusion,
+		rrfK: 			params.RRFK,
+		vectorWeight: 	params.VectorWeight,
+		language: 		params.Language,
+	}
+}
+
+// cachedRanking is a ranking by document id together with the analyzed query and its embedding,
+// which passages are cut with. It is valid while the index stays at generation.
+type cachedRanking struct {
+	generation 	uint64
+	query 		[]model.QueryTerm
+	queryVec 	[][]float64
+	hits 		[]cachedHit
+	truncated 	bool
+}
+
+type cachedHit struct {
+	id 			[32]byte
+	score 		float64
+	signals 	[]SignalScore
+	bestChunk 	int
+	similarity 	float64
+}
+
+func (s *Searcher) cacheRanking(key resultKey, generation uint64, hits []Hit, cs *candidateSet) {
+	entry := &cachedRanking{
+		generation: generation,
+		query: 		cs.query,
+		queryVec: 	cs.queryVec,
+		hits: 		make([]cachedHit, 0, min(len(hits), cachedHits)),
+		truncated: 	len(hits) > cachedHits,
+	}
+	for _, hit := range hits[:min(len(hits), cachedHits)] {
+		r := cs.rank[hit.Doc.Id]
+		entry.hits = append(entry.hits, cachedHit{id: hit.Doc.Id, score: hit.Score, signals: hit.Signals, bestChunk: r.bestChunk, similarity: r.wordsCos})
+	}
+	s.results.Add(key, entry)
+}
+
+// cachedSearch answers from a ranking cached at the current generation with the analyzed query,
+// documents and passages are read again. It reports false when the query has to be ranked.
+func (s *Searcher) cachedSearch(key resultKey, params Params) ([]Hit, []model.QueryTerm, bool) {
+	entry, ok := s.results.Get(key)
+	if !ok || entry.generation != s.idx.Generation() || (entry.truncated && params.MaxLen > len(entry.hits)) {
+		return nil, nil, false
+	}
+	if len(entry.hits) == 0 {
+		return nil, entry.query, true
+	}
+	cs := &candidateSet{raw: key.query, query: entry.query, queryVec: entry.queryVec, rank: map[[32]byte]requestRanking{}}
+	hits := make([]Hit, 0, min(len(entry.hits), params.MaxLen))
+	for _, h := range entry.hits[:min(len(entry.hits), params.MaxLen)] {
+		doc, err := s.idx.GetDocumentByID(h.id)
+		if err != nil || doc == nil {
+			return nil, nil, false
+		}
+		cs.rank[h.id] = requestRanking{bestChunk: h.bestChunk, wordsCos: h.similarity}
+		hits = append(hits, Hit{Doc: doc, Score: h.score, Signals: h.signals})
+	}
+	for i := range hits {
+		hits[i].Passage = s.passage(cs, hits[i].Doc)
+	}
+	return hits, entry.query, true
+}
@@ -0,0 +1,91 @@
+package searcher
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/box1bs/monocle/configs"
+	"github.com/box1bs/monocle/internal/model"
+)
+
+// rankingOf caches n hits for query at generation and returns the searcher with the fake index.
+func rankingOf(t *testing.T, query string, n int, generation uint64) (*Searcher, *fakeIndex) {
+	t.Helper()
+	idx := &fakeIndex{docs: map[[32]byte]*model.Document{}, generation: generation}
+	s := NewSearcher(idx, nil)
+	cs := &candidateSet{query: []model.QueryTerm{{Term: "go"}}, rank: map[[32]byte]requestRanking{}}
+	hits := make([]Hit, 0, n)
+	for i := range n {
+		doc := &model.Document{Id: [32]byte{byte(i), byte(i >> 8)}, URL: "doc"}
+		idx.docs[doc.Id] = doc
+		hits = append(hits, Hit{Doc: doc, Score: float64(n - i)})
+	}
+	s.cacheRanking(newResultKey(query, Params{}), generation, hits, cs)
+	return s, idx
+}
+
+func TestCachedSearch(t *testing.T) {
+	s, _ := rankingOf(t, "Go", 3, 1)
+	// the key is the normalized query
+	hits, terms, ok := s.cachedSearch(newResultKey("  go ", Params{}), Params{MaxLen: 2})
+	if !ok {
+		t.Fatal("cached ranking missed")
+	}
+	if len(hits) != 2 || hits[0].Score != 3 || hits[1].Score != 2 {
+		t.Fatalf("cached hits %+v", hits)
+	}
+	if !reflect.DeepEqual(terms, []model.QueryTerm{{Term: "go"}}) {
+		t.Fatalf("cached terms %+v", terms)
+	}
+	if _, _, ok := s.cachedSearch(newResultKey("go", Params{Pipeline: "fusion"}), Params{MaxLen: 2}); ok {
+		t.Fatal("a ranking of another pipeline was served")
+	}
+}
+
+func TestCachedSearchGeneration(t *testing.T) {
+	s, idx := rankingOf(t, "go", 3, 1)
+	idx.generation = 2
+	if _, _, ok := s.cachedSearch(newResultKey("go", Params{}), Params{MaxLen: 10}); ok {
+		t.Fatal("a ranking of an older generation was served")
+	}
+}
+
+func TestCachedSearchDepth(t *testing.T) {
+	s, _ := rankingOf(t, "go", cachedHits + 1, 1)
+	key := newResultKey("go", Params{})
+	if hits, _, ok := s.cachedSearch(key, Params{MaxLen: cachedHits}); !ok || len(hits) != cachedHits {
+		t.Fatalf("ranking within the cached depth: %d hits, %v", len(hits), ok)
+	}
+	if _, _, ok := s.cachedSearch(key, Params{MaxLen: cachedHits + 1}); ok {
+		t.Fatal("a truncated ranking answered a deeper request")
+	}
+}
+
+func TestCachedSearchMissingDocument(t *testing.T) {
+	s, idx := rankingOf(t, "go", 2, 1)
+	delete(idx.docs, [32]byte{1})
+	if _, _, ok := s.cachedSearch(newResultKey("go", Params{}), Params{MaxLen: 10}); ok {
+		t.Fatal("a ranking with a missing document was served")
+	}
+}
+
+func TestCachedEmptyRanking(t *testing.T) {
+	s, _ := rankingOf(t, "go", 0, 1)
+	hits, terms, ok := s.cachedSearch(newResultKey("go", Params{}), Params{MaxLen: 10})
+	if !ok || hits != nil || len(terms) != 1 {
+		t.Fatalf("empty ranking: hits %v, terms %v, %v", hits, terms, ok)
+	}
+}
+
+func TestLoadPipelinesPurgesRankings(t *testing.T) {
+	s, _ := rankingOf(t, "go", 3, 1)
+	if s.results.Len() != 1 {
+		t.Fatalf("%d cached rankings, want 1", s.results.Len())
+	}
+	if err := s.LoadPipelines(configs.RankingConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := s.cachedSearch(newResultKey("go", Params{}), Params{MaxLen: 10}); ok || s.results.Len() != 0 {
+		t.Fatal("rankings survived new pipelines")
+	}
+}
@@ -0,0 +1,143 @@
+package searcher
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/box1bs/monocle/internal/model"
+)
+
+// TermExplanation is the contribution of one query term. Suggestions are the spelling suggestions
+// for the word as typed, best first, and CorrectedFrom is the typed word the term replaced when
+// the document was found by the corrected query.
+type TermExplanation struct {
+	Term 			string 		`json:"term"`
+	Text 			string 		`json:"text,omitempty"`
+	Suggestions 	[]string 	`json:"suggestions,omitempty"`
+	CorrectedFrom 	string 		`json:"corrected_from,omitempty"`
+	ID 				int 		`json:"id"`
+	Weight 			float64 	`json:"weight"`
+	Matched 		bool 		`json:"matched"`
+	DocFreq 		int 		`json:"doc_freq"`
+	IDF 			float64 	`json:"idf"`
+	TF 				int 		`json:"tf"`
+	TfIdf 			float64 	`json:"tf_idf"`
+	BM25 			float64 	`json:"bm25"`
+	InHeader 		bool 		`json:"in_header"`
+	Positions 		[]int 		`json:"positions,omitempty"`
+}
+
+// Explanation mirrors requestRanking of one document for one query together with
+// the per term contributions and the position the document got in the final order.
+// Cosine is the similarity of the best matching chunk, BestChunk its index and Passage its text.
+// Clicks and QueryClicks are the log scaled clicks on the document overall and from this query, Dwell
+// the mean dwell time of its clicks in seconds.
+type Explanation struct {
+	Query 			string 				`json:"query"`
+	DocID 			string 				`json:"doc_id"`
+	URL 			string 				`json:"url"`
+	Position 		int 				`json:"position"`
+	Terms 			[]TermExplanation 	`json:"terms"`
+	TfIdf 			float64 			`json:"tf_idf"`
+	BM25 			float64 			`json:"bm25"`
+	Cosine 			float64 			`json:"cosine"`
+	Euclidean 		float64 			`json:"euclidean"`
+	BestChunk 		int 				`json:"best_chunk"`
+	Passage 		*Passage 			`json:"passage,omitempty"`
+	QueryCoverage 	float64 			`json:"query_coverage"`
+	QueryDensity 	float64 			`json:"query_density"`
+	IncludesWords 	int 				`json:"includes_words"`
+	HasWordInHeader bool 				`json:"has_word_in_header"`
+	Fusion 			float64 			`json:"fusion,omitempty"`
+	Clicks 			float64 			`json:"clicks"`
+	QueryClicks 	float64 			`json:"query_clicks"`
+	Dwell 			float64 			`json:"dwell"`
+	Score 			float64 			`json:"score"`
+	Signals 		[]SignalScore 		`json:"signals"`
+}
+
+func (s *Searcher) Explain(query string, docID [32]byte) (*Explanation, error) {
+	return s.ExplainWith(query, docID, Params{})
+}
+
+// ExplainWith explains a document for the query ranked with params, MaxLen is ignored.
+// A document that was not retrieved gets position 0 and only its term statistics.
+// Documents missing from the index and queries without any term give model.ErrNotFound.
+func (s *Searcher) ExplainWith(query string, docID [32]byte, params Params) (*Explanation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hits, cs, err := s.rankAll(query, nil, params)
+	if err != nil {
+		return nil, err
+	}
+	for i, hit := range hits {
+		if hit.Doc.Id == docID {
+			e := cs.explain(hit.Doc, i + 1, hit)
+			e.Passage = s.passage(cs, hit.Doc)
+			return e, nil
+		}
+	}
+
+	if len(hits) == 0 && len(cs.query) == 0 {
+		return nil, fmt.Errorf("query %q has no searchable terms: %w", query, model.ErrNotFound)
+	}
+	doc, err := s.idx.GetDocumentByID(docID)
+	if err != nil {
+		return nil, err
+	}
+	return cs.explain(doc, 0, Hit{Doc: doc}), nil
+}
+
+func (cs *candidateSet) explain(doc *model.Document, position int, hit Hit) *Explanation {
+	r := cs.rank[doc.Id]
+	e := &Explanation{
+		Query: 				cs.raw,
+		DocID: 				hex.EncodeToString(doc.Id[:]),
+		URL: 				doc.URL,
+		Position: 			position,
+		TfIdf: 				r.tf_idf,
+		BM25: 				r.bm25,
+		Cosine: 			r.wordsCos,
+		Euclidean: 			r.dpq,
+		BestChunk: 			r.bestChunk,
+		QueryCoverage: 		r.queryCoverage,
+		QueryDensity: 		r.queryDencity,
+		IncludesWords: 		r.includesWords,
+		HasWordInHeader: 	r.hasWordInHeader,
+		Fusion: 			r.fusion,
+		Clicks: 			r.clicks,
+		QueryClicks: 		r.queryClicks,
+		Dwell: 				r.dwell,
+		Score: 				hit.Score,
+		Signals: 			hit.Signals,
+	}
+
+	for _, qt := range cs.query {
+		te := TermExplanation{
+			Term: 			qt.Term,
+			Text: 			qt.Text,
+			Suggestions: 	qt.Suggestions,
+			ID: 			qt.ID,
+			Weight: 		qt.Weight,
+			DocFreq: 		cs.stats.DF[qt.ID],
+		}
+		if qt.ID != 0 {
+			te.IDF = cs.idf(qt.ID)
+		}
+		if item, ok := cs.postings[qt.ID][doc.Id]; ok {
+			te.Matched = true
+			te.TF = item.Count
+			if doc.WordCount > 0 {
+				te.TfIdf = float64(item.Count) / float64(doc.WordCount) * te.IDF * qt.Weight
+			}
+			te.BM25 = culcBM25(te.IDF * qt.Weight, float64(item.Count), doc, cs.avgLen)
+			for _, p := range item.Positions {
+				te.Positions = append(te.Positions, p.I)
+				te.InHeader = te.InHeader || p.Type == 'h'
+			}
+		}
+		e.Terms = append(e.Terms, te)
+	}
+	return e
+}
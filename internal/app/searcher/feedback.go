@@ -0,0 +1,93 @@
+package searcher
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/box1bs/monocle/internal/model"
+)
+
+type feedbackStore interface {
+	SaveClick(*model.Click) error
+	LoadClickStats(func(string, [32]byte, model.Popularity)) error
+}
+
+// clickStats is the click popularity of documents over all queries and per query.
+type clickStats struct {
+	mu 		sync.RWMutex
+	docs 	map[[32]byte]model.Popularity
+	queries map[string]map[[32]byte]model.Popularity
+}
+
+func newClickStats() *clickStats {
+	return &clickStats{docs: map[[32]byte]model.Popularity{}, queries: map[string]map[[32]byte]model.Popularity{}}
+}
+
+func (cs *clickStats) add(query string, docID [32]byte, p model.Popularity) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	doc := cs.docs[docID]
+	cs.docs[docID] = model.Popularity{Clicks: doc.Clicks + p.Clicks, Dwell: doc.Dwell + p.Dwell}
+	if cs.queries[query] == nil {
+		cs.queries[query] = map[[32]byte]model.Popularity{}
+	}
+	q := cs.queries[query][docID]
+	cs.queries[query][docID] = model.Popularity{Clicks: q.Clicks + p.Clicks, Dwell: q.Dwell + p.Dwell}
+}
+
+// features are the log scaled clicks on the document, its log scaled clicks from the results
+// of query and the mean dwell time of its clicks in seconds.
+func (cs *clickStats) features(query string, docID [32]byte) (clicks, queryClicks, dwell float64) {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	doc := cs.docs[docID]
+	if doc.Clicks == 0 {
+		return 0, 0, 0
+	}
+	clicks = math.Log1p(float64(doc.Clicks))
+	queryClicks = math.Log1p(float64(cs.queries[query][docID].Clicks))
+	dwell = doc.Dwell.Seconds() / float64(doc.Clicks)
+	return clicks, queryClicks, dwell
+}
+
+// SetFeedback stores reported clicks in store and loads the popularity it holds,
+// without a store clicks still count until the searcher stops.
+func (s *Searcher) SetFeedback(store feedbackStore) error {
+	clicks := newClickStats()
+	if err := store.LoadClickStats(clicks.add); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.feedback, s.clicks = store, clicks
+	return nil
+}
+
+// Feedback records that the document was opened from the results of query at position,
+// 1-based, and read for dwell. The click counts towards the popularity signals right away.
+func (s *Searcher) Feedback(query string, docID [32]byte, position int, dwell time.Duration) error {
+	if _, err := s.idx.GetDocumentByID(docID); err != nil {
+		return fmt.Errorf("unknown document %x: %w", docID, err)
+	}
+	query = normalizeQuery(strings.TrimPrefix(query, LiteralPrefix))
+	if query == "" {
+		return fmt.Errorf("empty query: %w", model.ErrInvalid)
+	}
+	c := &model.Click{Time: time.Now(), Query: query, DocID: docID, Position: position, Dwell: dwell}
+
+	s.mu.RLock()
+	store, clicks := s.feedback, s.clicks
+	s.mu.RUnlock()
+	if store != nil {
+		if err := store.SaveClick(c); err != nil {
+			return err
+		}
+	}
+	clicks.add(query, docID, model.Popularity{}.Add(*c))
+	// rankings cached before the click was counted would keep its old popularity
+	s.results.Purge()
+	return nil
+}
@@ -0,0 +1,68 @@
+package searcher
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/box1bs/monocle/internal/model"
+)
+
+const (
+	FusionRRF = "rrf"
+	FusionConvex = "convex"
+
+	defaultRRFK = 60
+	defaultVectorWeight = 0.5
+)
+
+type ranked struct {
+	id 		[32]byte
+	score 	float64
+}
+
+// fuse combines the lexical and the vector list, both ordered best first.
+// Reciprocal rank fusion sums 1/(k+rank) over the lists a document appears in and ignores
+// raw scores. The convex combination min-max normalizes each list and mixes them with
+// the vector weight, a document missing from a list gets 0 for it.
+func fuse(params Params, lexical, vector []ranked) (map[[32]byte]float64, error) {
+	fused := make(map[[32]byte]float64, len(lexical) + len(vector))
+	switch params.Fusion {
+	case "", FusionRRF:
+		k := float64(params.RRFK)
+		if k <= 0 {
+			k = defaultRRFK
+		}
+		for _, list := range [][]ranked{lexical, vector} {
+			for i, r := range list {
+				fused[r.id] += 1 / (k + float64(i + 1))
+			}
+		}
+	case FusionConvex:
+		w := params.VectorWeight
+		if w <= 0 {
+			w = defaultVectorWeight
+		}
+		if w > 1 {
+			return nil, fmt.Errorf("vector weight must be within (0, 1], got %v: %w", w, model.ErrInvalid)
+		}
+		for _, list := range []struct{
+			items 	[]ranked
+			weight 	float64
+		}{{lexical, 1 - w}, {vector, w}} {
+			lo, hi := math.Inf(1), math.Inf(-1)
+			for _, r := range list.items {
+				lo, hi = min(lo, r.score), max(hi, r.score)
+			}
+			for _, r := range list.items {
+				norm := 1.0
+				if hi > lo {
+					norm = (r.score - lo) / (hi - lo)
+				}
+				fused[r.id] += list.weight * norm
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown fusion method %q: %w", params.Fusion, model.ErrInvalid)
+	}
+	return fused, nil
+}
@@ -0,0 +1,52 @@
+package searcher
+
+import (
+	"strings"
+
+	"github.com/box1bs/monocle/internal/model"
+)
+
+// Passage is the document chunk closest to the query, Start and End are its byte offsets
+// in the document text and Highlights the byte ranges of query terms within Text.
+type Passage struct {
+	Text 		string 		`json:"text"`
+	Start 		int 		`json:"start"`
+	End 		int 		`json:"end"`
+	Similarity 	float64 	`json:"similarity"`
+	Highlights 	[][2]int 	`json:"highlights,omitempty"`
+}
+
+func (s *Searcher) passage(cs *candidateSet, doc *model.Document) *Passage {
+	r, ok := cs.rank[doc.Id]
+	if !ok || len(cs.queryVec) == 0 {
+		return nil
+	}
+	text, chunks, err := s.idx.GetChunks(doc.Id)
+	if err != nil || r.bestChunk >= len(chunks) {
+		return nil
+	}
+	chunk := chunks[r.bestChunk]
+	p := &Passage{
+		Text: 		text[chunk.Start:chunk.End],
+		Start: 		chunk.Start,
+		End: 		chunk.End,
+		Similarity: r.wordsCos,
+	}
+	p.Highlights = s.idx.Highlight(p.Text, doc.Lang, cs.query)
+	return p
+}
+
+// Marked returns the passage text with every highlight wrapped into open and close.
+func (p *Passage) Marked(open, close string) string {
+	b := strings.Builder{}
+	last := 0
+	for _, h := range p.Highlights {
+		b.WriteString(p.Text[last:h[0]])
+		b.WriteString(open)
+		b.WriteString(p.Text[h[0]:h[1]])
+		b.WriteString(close)
+		last = h[1]
+	}
+	b.WriteString(p.Text[last:])
+	return b.String()
+}
@@ -0,0 +1,168 @@
+package searcher
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/box1bs/monocle/configs"
+	"github.com/box1bs/monocle/internal/model"
+)
+
+const (
+	linearMode 	= "linear"
+	cascadeMode = "cascade"
+)
+
+type scorer func(requestRanking) float64
+
+var scorers = map[string]scorer{
+	"tf_idf": 			func(r requestRanking) float64 { return r.tf_idf },
+	"bm25": 			func(r requestRanking) float64 { return r.bm25 },
+	"cosine": 			func(r requestRanking) float64 { return r.wordsCos },
+	"euclidean": 		func(r requestRanking) float64 { return r.dpq },
+	"query_coverage": 	func(r requestRanking) float64 { return r.queryCoverage },
+	"query_density": 	func(r requestRanking) float64 { return r.queryDencity },
+	"includes_words": 	func(r requestRanking) float64 { return float64(r.includesWords) },
+	"header_match": 	func(r requestRanking) float64 {
+		if r.hasWordInHeader {
+			return 1.0
+		}
+		return 0.0
+	},
+	"fusion": 			func(r requestRanking) float64 { return r.fusion },
+	"click_popularity": func(r requestRanking) float64 { return r.clicks },
+	"query_clicks": 	func(r requestRanking) float64 { return r.queryClicks },
+	"dwell_time": 		func(r requestRanking) float64 { return r.dwell },
+}
+
+type signal struct {
+	name 		string
+	weight 		float64
+	precision 	int
+	score 		scorer
+}
+
+type pipeline struct {
+	name 	string
+	mode 	string
+	signals []signal
+}
+
+type SignalScore struct {
+	Name 			string 	`json:"name"`
+	Value 			float64 `json:"value"`
+	Weight 			float64 `json:"weight"`
+	Contribution 	float64 `json:"contribution"`
+}
+
+// Hit is a ranked document, Corrected marks hits only the spelling corrected query found.
+type Hit struct {
+	Doc 		*model.Document
+	Score 		float64
+	Signals 	[]SignalScore
+	Passage 	*Passage
+	Explanation *Explanation
+	Corrected 	bool
+}
+
+// defaultPipeline reproduces the historical order: cosine and euclidean distance compared
+// with two decimals, then bm25, matched words, density and tf-idf.
+func defaultPipeline() *pipeline {
+	p, _ := newPipeline("default", configs.PipelineConfig{
+		Mode: cascadeMode,
+		Signals: []configs.SignalConfig{
+			{Name: "cosine", Weight: 1, Precision: 2},
+			{Name: "euclidean", Weight: -1, Precision: 2},
+			{Name: "bm25", Weight: 1},
+			{Name: "includes_words", Weight: 1},
+			{Name: "query_density", Weight: 1},
+			{Name: "tf_idf", Weight: 1},
+		},
+	})
+	return p
+}
+
+// fusionPipeline orders hybrid results by the fused score alone, it is used when
+// a hybrid query does not name a pipeline.
+func fusionPipeline() *pipeline {
+	p, _ := newPipeline("fusion", configs.PipelineConfig{
+		Mode: linearMode,
+		Signals: []configs.SignalConfig{{Name: "fusion", Weight: 1}},
+	})
+	return p
+}
+
+func newPipeline(name string, cfg configs.PipelineConfig) (*pipeline, error) {
+	mode := cfg.Mode
+	if mode == "" {
+		mode = linearMode
+	}
+	if mode != linearMode && mode != cascadeMode {
+		return nil, fmt.Errorf("pipeline %s: unknown mode %q", name, cfg.Mode)
+	}
+	if len(cfg.Signals) == 0 {
+		return nil, fmt.Errorf("pipeline %s: no signals", name)
+	}
+
+	p := &pipeline{name: name, mode: mode}
+	for _, sc := range cfg.Signals {
+		score, ok := scorers[sc.Name]
+		if !ok {
+			return nil, fmt.Errorf("pipeline %s: unknown signal %q", name, sc.Name)
+		}
+		p.signals = append(p.signals, signal{name: sc.Name, weight: sc.Weight, precision: sc.Precision, score: score})
+	}
+	return p, nil
+}
+
+func (p *pipeline) breakdown(r requestRanking) (float64, []SignalScore) {
+	total := 0.0
+	signals := make([]SignalScore, 0, len(p.signals))
+	for _, sig := range p.signals {
+		value := sig.score(r)
+		contribution := sig.weight * value
+		total += contribution
+		signals = append(signals, SignalScore{Name: sig.name, Value: value, Weight: sig.weight, Contribution: contribution})
+	}
+	return total, signals
+}
+
+func (p *pipeline) score(docs []*model.Document, rank map[[32]byte]requestRanking) []Hit {
+	hits := make([]Hit, 0, len(docs))
+	for _, doc := range docs {
+		score, signals := p.breakdown(rank[doc.Id])
+		hits = append(hits, Hit{Doc: doc, Score: score, Signals: signals})
+	}
+	p.order(hits)
+	return hits
+}
+
+// order sorts hits by the weighted sum in linear mode, in cascade mode the weighted signals
+// are compared one after another, truncated to their precision when it is set.
+func (p *pipeline) order(hits []Hit) {
+	if p.mode == linearMode {
+		sort.SliceStable(hits, func(i, j int) bool {
+			return hits[i].Score > hits[j].Score
+		})
+		return
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		for k, sig := range p.signals {
+			a, b := hits[i].Signals[k].Contribution, hits[j].Signals[k].Contribution
+			if sig.precision > 0 {
+				a, b = truncate(a, sig.precision), truncate(b, sig.precision)
+			}
+			if a != b {
+				return a > b
+			}
+		}
+		return false
+	})
+}
+
+func truncate(f float64, precision int) float64 {
+	scale := math.Pow(10, float64(precision))
+	return math.Trunc(f * scale) / scale
+}
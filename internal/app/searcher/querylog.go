@@ -0,0 +1,54 @@
+package searcher
+
+import (
+	"log"
+	"time"
+
+	"github.com/box1bs/monocle/internal/model"
+)
+
+// logTopResults is the number of result URLs kept in a query log entry.
+const logTopResults = 5
+
+type queryLog interface {
+	LogQuery(*model.QueryLogEntry) error
+}
+
+// SetQueryLog makes the searcher write every query it serves to ql, nil stops logging.
+func (s *Searcher) SetQueryLog(ql queryLog) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queryLog = ql
+}
+
+func (s *Searcher) logQuery(start time.Time, res *Results, terms []model.QueryTerm) {
+	s.mu.RLock()
+	ql := s.queryLog
+	s.mu.RUnlock()
+	if ql == nil {
+		return
+	}
+
+	entry := &model.QueryLogEntry{
+		Time: 		start,
+		Query: 		res.Query,
+		Corrected: 	res.Corrected,
+		Results: 	len(res.Hits),
+		Latency: 	time.Since(start),
+	}
+	for _, term := range terms {
+		entry.Terms = append(entry.Terms, term.Term)
+		if len(term.Suggestions) > 0 && !res.Literal {
+			if entry.Corrections == nil {
+				entry.Corrections = map[string]string{}
+			}
+			entry.Corrections[term.Text] = term.Suggestions[0]
+		}
+	}
+	for _, hit := range res.Hits[:min(len(res.Hits), logTopResults)] {
+		entry.Top = append(entry.Top, hit.Doc.URL)
+	}
+	if err := ql.LogQuery(entry); err != nil {
+		log.Println(err)
+	}
+}
@@ -2,70 +2,459 @@ package searcher
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"math"
+	"slices"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/box1bs/monocle/configs"
+	"github.com/box1bs/monocle/internal/app/embedding"
 	"github.com/box1bs/monocle/internal/model"
+	"github.com/box1bs/monocle/pkg/lru"
+	"github.com/box1bs/monocle/pkg/trie"
 )
 
 type index interface {
 	GetDocumentsByWord(int) (map[[32]byte]*model.WordCountAndPositions, error)
-	GetDocumentsCount() (int, error)
 	GetDocumentByID([32]byte) (*model.Document, error)
-	GetAVGLen() (float64, error)
-	HandleTextQuery(string) ([]int, error)
+	CorpusStats(...int) (*model.CorpusStats, error)
+	AnalyzeQuery(string, string) ([]model.QueryTerm, error)
+	SearchVectors([]float64, int) ([]model.VectorMatch, error)
+	GetEmbeddings([32]byte) ([][]float64, error)
+	GetChunks([32]byte) (string, []model.Chunk, error)
+	Highlight(string, string, []model.QueryTerm) [][2]int
+	Dictionary(func(string, int)) error
+	WordsContaining(string) ([]string, error)
+	CountQuery(string) error
+	LoadQueryCounts(func(string, int)) error
+	Generation() uint64
 }
 
-type vectorizer interface {
-	Vectorize(string, context.Context) ([][]float64, error)
+type ranker interface {
+	Predict([]float64) float64
 }
 
 type Searcher struct {
 	mu         	*sync.RWMutex
-	vectorizer  vectorizer
+	vectorizer  embedding.Provider
 	idx 		index
+	ranker 		ranker
+	rerankTop 	int
+	pipelines 	map[string]*pipeline
+	pipeline 	string
+	words 		*trie.Trie
+	queries 	*trie.Trie
+	queryLog 	queryLog
+	feedback 	feedbackStore
+	clicks 		*clickStats
+	results 	*lru.Cache[resultKey, *cachedRanking]
 }
 
-func NewSearcher(idx index, vec vectorizer) *Searcher {
+func NewSearcher(idx index, vec embedding.Provider) *Searcher {
 	return &Searcher{
 		mu:        	&sync.RWMutex{},
 		vectorizer: vec,
 		idx:       	idx,
+		pipelines: 	map[string]*pipeline{"default": defaultPipeline(), "fusion": fusionPipeline()},
+		pipeline: 	"default",
+		words: 		trie.New(),
+		queries: 	trie.New(),
+		clicks: 	newClickStats(),
+		results: 	lru.New[resultKey, *cachedRanking](resultCacheSize),
 	}
 }
 
+// LoadPipelines adds the configured ranking pipelines, the builtin "default" cascade
+// and "fusion" stay available unless the config redefines them.
+func (s *Searcher) LoadPipelines(cfg configs.RankingConfig) error {
+	pipelines := map[string]*pipeline{"default": defaultPipeline(), "fusion": fusionPipeline()}
+	for name, pc := range cfg.Pipelines {
+		p, err := newPipeline(name, pc)
+		if err != nil {
+			return err
+		}
+		pipelines[name] = p
+	}
+
+	def := cfg.Default
+	if def == "" {
+		def = "default"
+	}
+	if _, ok := pipelines[def]; !ok {
+		return fmt.Errorf("default pipeline %q is not defined", def)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pipelines = pipelines
+	s.pipeline = def
+	s.results.Purge()
+	return nil
+}
+
+func (s *Searcher) Pipelines() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	names := make([]string, 0, len(s.pipelines))
+	for name := range s.pipelines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetRanker enables reranking of the first topK candidates with a learned model, nil disables it.
+func (s *Searcher) SetRanker(r ranker, topK int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ranker = r
+	s.rerankTop = topK
+	s.results.Purge()
+}
+
 type requestRanking struct {
 	tf_idf 			float64
 	bm25 			float64
 	wordsCos		float64
 	dpq				float64
 	queryCoverage	float64
-	queryDencity 	int
+	queryDencity 	float64
 	includesWords 	int
 	hasWordInHeader bool
+	fusion 			float64
+	bestChunk 		int
+	clicks 			float64
+	queryClicks 	float64
+	dwell 			float64
 	//any ranking scores
 }
 
-func (s *Searcher) Search(query string, quorum float64, maxLen int) []*model.Document {
+// FeatureNames is the order of values in the vectors returned by Features and consumed by learned models.
+var FeatureNames = []string{
+	"tf_idf",
+	"bm25",
+	"cosine",
+	"euclidean",
+	"query_coverage",
+	"query_density",
+	"includes_words",
+	"header_match",
+	"click_popularity",
+	"query_clicks",
+	"dwell_time",
+}
+
+func (r requestRanking) features() []float64 {
+	features := make([]float64, len(FeatureNames))
+	for i, name := range FeatureNames {
+		features[i] = scorers[name](r)
+	}
+	return features
+}
+
+type FeatureRow struct {
+	Doc 		*model.Document
+	Features 	[]float64
+}
+
+// Features returns the ranking features of every candidate for the query in the default pipeline order, without learned reranking.
+func (s *Searcher) Features(query string, quorum float64) ([]FeatureRow, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	
-	rank := make(map[[32]byte]requestRanking)
 
-	terms, err := s.idx.HandleTextQuery(query)
+	cs, err := s.candidates(query, nil, Params{Quorum: quorum})
+	if err != nil {
+		return nil, err
+	}
+	hits := s.pipelines[s.pipeline].score(cs.docs, cs.rank)
+
+	rows := make([]FeatureRow, 0, len(hits))
+	for _, hit := range hits {
+		rows = append(rows, FeatureRow{Doc: hit.Doc, Features: cs.rank[hit.Doc.Id].features()})
+	}
+	return rows, nil
+}
+
+const (
+	RetrievalLexical = "lexical"
+	RetrievalSemantic = "semantic"
+	RetrievalHybrid = "hybrid"
+)
+
+// DefaultQuorum keeps every document containing a query term. tf-idf is normalised by document
+// length, so a term mentioned once in a long body scores far below one, and any fixed cut would
+// drop such documents regardless of the rest of the ranking.
+const DefaultQuorum = 0.0
+
+// Params selects how a single query is ranked, an empty Pipeline uses the configured default.
+// Quorum is the minimal length normalised tf-idf, summed over the query terms, of a lexical candidate.
+// Retrieval chooses where candidates come from: documents containing query terms, the nearest
+// documents in the vector index, which need not share any term with the query and skip the quorum,
+// or both lists fused with Fusion. Hybrid queries without a Pipeline are ordered by the fused score.
+// RRFK is the rank constant of reciprocal rank fusion, VectorWeight the share of the vector list
+// in the convex combination, zero values use 60 and 0.5.
+// Language is the code of the analyzer the query is stemmed with, empty detects it from the query.
+// With Explain set every hit carries its full explanation.
+type Params struct {
+	Quorum 			float64
+	MaxLen 			int
+	Pipeline 		string
+	Retrieval 		string
+	Fusion 			string
+	RRFK 			int
+	VectorWeight 	float64
+	Language 		string
+	Explain 		bool
+}
+
+// Search returns the documents SearchWith finds for query without correcting it, the query log
+// still records the corrections a query that isn't literal would get.
+func (s *Searcher) Search(query string, quorum float64, maxLen int) []*model.Document {
+	start := time.Now()
+	hits, terms, err := s.search(query, nil, Params{Quorum: quorum, MaxLen: maxLen})
 	if err != nil {
 		log.Println(err)
 		return nil
 	}
+	res := &Results{Query: query, Hits: hits}
+	if literal, ok := strings.CutPrefix(query, LiteralPrefix); ok {
+		res.Query, res.Literal = strings.TrimSpace(literal), true
+	} else {
+		res.Corrected = correctQuery(query, terms)
+	}
+	s.logQuery(start, res, terms)
+	docs := make([]*model.Document, 0, len(hits))
+	for _, hit := range hits {
+		docs = append(docs, hit.Doc)
+	}
+	return docs
+}
+
+// SearchWith ranks the candidates with the selected pipeline and returns them with their per signal scores.
+// Rankings are cached by normalized query until the index changes, explained searches are always ranked.
+func (s *Searcher) SearchWith(query string, params Params) ([]Hit, error) {
+	hits, _, err := s.search(query, nil, params)
+	return hits, err
+}
+
+// search is SearchWith for a query whose terms may already be analyzed, nil terms are analyzed here.
+// It also returns the analyzed terms.
+func (s *Searcher) search(query string, terms []model.QueryTerm, params Params) ([]Hit, []model.QueryTerm, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key := newResultKey(query, params)
+	if !params.Explain {
+		if hits, cached, ok := s.cachedSearch(key, params); ok {
+			return hits, cached, nil
+		}
+	}
+	generation := s.idx.Generation()
+	hits, cs, err := s.rankAll(query, terms, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.cacheRanking(key, generation, hits, cs)
+	if len(hits) == 0 {
+		return nil, cs.query, nil
+	}
+	hits = hits[:min(len(hits), params.MaxLen)]
+
+	for i := range hits {
+		hits[i].Passage = s.passage(cs, hits[i].Doc)
+	}
+	if params.Explain {
+		for i := range hits {
+			hits[i].Explanation = cs.explain(hits[i].Doc, i + 1, hits[i])
+			hits[i].Explanation.Passage = hits[i].Passage
+		}
+	}
+	return hits, cs.query, nil
+}
+
+// Validate checks the pipeline, retrieval mode and fusion settings of params without searching,
+// an unusable setting is reported wrapped in model.ErrInvalid.
+func (s *Searcher) Validate(params Params) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if _, err := s.pipelineFor(params); err != nil {
+		return err
+	}
+	if _, err := retrievalMode(params.Retrieval); err != nil {
+		return err
+	}
+	_, err := fuse(params, nil, nil)
+	return err
+}
+
+func (s *Searcher) pipelineFor(params Params) (*pipeline, error) {
+	name := params.Pipeline
+	if name == "" {
+		name = s.pipeline
+		if params.Retrieval == RetrievalHybrid {
+			name = "fusion"
+		}
+	}
+	p, ok := s.pipelines[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown ranking pipeline %q: %w", name, model.ErrInvalid)
+	}
+	return p, nil
+}
+
+func retrievalMode(mode string) (string, error) {
+	switch mode {
+	case "":
+		return RetrievalLexical, nil
+	case RetrievalLexical, RetrievalSemantic, RetrievalHybrid:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown retrieval mode %q: %w", mode, model.ErrInvalid)
+}
+
+func (s *Searcher) rankAll(query string, terms []model.QueryTerm, params Params) ([]Hit, *candidateSet, error) {
+	p, err := s.pipelineFor(params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cs, err := s.candidates(query, terms, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	hits := p.score(cs.docs, cs.rank)
+
+	if s.ranker != nil && s.rerankTop > 0 {
+		s.rerank(hits[:min(len(hits), s.rerankTop)], cs.rank)
+	}
+	return hits, cs, nil
+}
+
+func (s *Searcher) rerank(hits []Hit, rank map[[32]byte]requestRanking) {
+	for i := range hits {
+		pred := s.ranker.Predict(rank[hits[i].Doc.Id].features())
+		hits[i].Score = pred
+		hits[i].Signals = append(hits[i].Signals, SignalScore{Name: "learned_model", Value: pred, Weight: 1, Contribution: pred})
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Score > hits[j].Score
+	})
+}
+
+type candidateSet struct {
+	raw 		string
+	query 		[]model.QueryTerm
+	docs 		[]*model.Document
+	rank 		map[[32]byte]requestRanking
+	postings 	map[int]map[[32]byte]*model.WordCountAndPositions
+	stats 		*model.CorpusStats
+	avgLen 		float64
+	queryVec 	[][]float64
+}
+
+// idf is zero without counted documents, an index from before the corpus statistics has none
+// until they are rebuilt.
+func (cs *candidateSet) idf(term int) float64 {
+	if cs.stats.Docs == 0 {
+		return 0
+	}
+	return math.Log(float64(cs.stats.Docs) / float64(cs.stats.DF[term] + 1)) + 1.0
+}
+
+// phrasePostings are the postings of words following one another, with the positions of the first word.
+func (s *Searcher) phrasePostings(words []int) (map[[32]byte]*model.WordCountAndPositions, error) {
+	postings := make([]map[[32]byte]*model.WordCountAndPositions, 0, len(words))
+	for _, word := range words {
+		mp, err := s.idx.GetDocumentsByWord(word)
+		if err != nil {
+			return nil, err
+		}
+		postings = append(postings, mp)
+	}
+
+	phrase := make(map[[32]byte]*model.WordCountAndPositions)
+	for docID, first := range postings[0] {
+		following := make([]map[int]struct{}, 0, len(words) - 1)
+		for _, mp := range postings[1:] {
+			item, ok := mp[docID]
+			if !ok {
+				break
+			}
+			at := make(map[int]struct{}, len(item.Positions))
+			for _, p := range item.Positions {
+				at[p.I] = struct{}{}
+			}
+			following = append(following, at)
+		}
+		if len(following) < len(words) - 1 {
+			continue
+		}
+		var starts []model.Position
+		for _, p := range first.Positions {
+			matched := true
+			for k, at := range following {
+				if _, ok := at[p.I + k + 1]; !ok {
+					matched = false
+					break
+				}
+			}
+			if matched {
+				starts = append(starts, p)
+			}
+		}
+		if len(starts) > 0 {
+			phrase[docID] = &model.WordCountAndPositions{Count: len(starts), Positions: starts}
+		}
+	}
+	return phrase, nil
+}
+
+// candidates collects and scores the documents of query, terms are its analysis or nil to analyze it.
+func (s *Searcher) candidates(query string, queryTerms []model.QueryTerm, params Params) (*candidateSet, error) {
+	mode, err := retrievalMode(params.Retrieval)
+	if err != nil {
+		return nil, err
+	}
+
+	rank := make(map[[32]byte]requestRanking)
+
+	if queryTerms == nil {
+		if queryTerms, err = s.idx.AnalyzeQuery(query, params.Language); err != nil {
+			return nil, err
+		}
+	}
+	// a term repeated in the query counts with its highest weight, synonyms weigh less than query words
+	terms := make([]int, 0, len(queryTerms))
+	weights := make(map[int]float64, len(queryTerms))
+	termPositions := make(map[int][]int, len(queryTerms))
+	positions := map[int]struct{}{}
+	phrases := map[int][]int{}
+	for _, term := range queryTerms {
+		if term.ID < 0 {
+			phrases[term.ID] = term.Phrase
+		}
+		terms = append(terms, term.ID)
+		weights[term.ID] = max(weights[term.ID], term.Weight)
+		termPositions[term.ID] = append(termPositions[term.ID], term.Pos)
+		positions[term.Pos] = struct{}{}
+	}
+	slices.Sort(terms)
+	terms = slices.Compact(terms)
+
 	index := make(map[int]map[[32]byte]*model.WordCountAndPositions)
 	for i := range terms {
-		mp, err := s.idx.GetDocumentsByWord(terms[i])
+		var mp map[[32]byte]*model.WordCountAndPositions
+		if words, ok := phrases[terms[i]]; ok {
+			mp, err = s.phrasePostings(words)
+		} else {
+			mp, err = s.idx.GetDocumentsByWord(terms[i])
+		}
 		if err != nil {
-			log.Println(err)
-			return nil
+			return nil, err
 		}
 		index[terms[i]] = mp
 	}
@@ -75,28 +464,26 @@ func (s *Searcher) Search(query string, quorum float64, maxLen int) []*model.Doc
 	var wg sync.WaitGroup
 	var rankMu sync.Mutex
 	var resultMu sync.Mutex
-	errCh := make(chan error, len(terms))
 
-	avgLen, err := s.idx.GetAVGLen()
+	stats, err := s.idx.CorpusStats(terms...)
 	if err != nil {
-		log.Println(err)
-		return nil
+		return nil, err
 	}
-
-	length, err := s.idx.GetDocumentsCount()
-	if err != nil {
-		log.Println(err)
-		return nil
+	// the postings of a phrase hold every document it occurs in
+	for id := range phrases {
+		stats.DF[id] = len(index[id])
 	}
+	avgLen := stats.AvgLen()
 
-	queryLen := len(terms)
+	cs := &candidateSet{raw: query, query: queryTerms, rank: rank, postings: index, stats: stats, avgLen: avgLen}
+	queryLen := len(positions)
 
 	for _, term := range terms {
 		wg.Add(1)
 		go func(term int) {
 			defer wg.Done()
 	
-			idf := math.Log(float64(length) / float64(len(index[term]) + 1)) + 1.0
+			idf := cs.idf(term) * weights[term]
 	
 			for docID, item := range index[term] {
 				doc, err := s.idx.GetDocumentByID(docID)
@@ -107,27 +494,27 @@ func (s *Searcher) Search(query string, quorum float64, maxLen int) []*model.Doc
 				rankMu.Lock()
 				r, ex := rank[docID]
 				if !ex {
-					rank[docID] = requestRanking{}
-				}
-				r.includesWords++
-				r.tf_idf += float64(doc.WordCount) * idf
-				r.bm25 += culcBM25(idf, float64(item.Count), doc, avgLen)
-				positions := [][]model.Position{}
-				if r.queryCoverage == 0.0 {
-					coverage := 0.0
-					for _, term := range terms {
-						positions = append(positions, item.Positions)
-						if len(index[term][docID].Positions) > 0 {
-							coverage++
+					// coverage counts query positions, a word is covered by any of its synonyms
+					positions := [][]model.Position{}
+					covered := map[int]struct{}{}
+					for _, t := range terms {
+						if entry, ok := index[t][docID]; ok && len(entry.Positions) > 0 {
+							positions = append(positions, entry.Positions)
+							for _, p := range termPositions[t] {
+								covered[p] = struct{}{}
+							}
 						}
 					}
-					r.queryDencity = calcQueryDencity(positions, queryLen)
-					r.queryCoverage = coverage / float64(queryLen)
+					r.queryCoverage = float64(len(covered)) / float64(queryLen)
+					r.queryDencity = calcQueryDencity(positions)
 				}
-				if !r.hasWordInHeader {
-					for i := 0; i < item.Count && !r.hasWordInHeader; i++ {
-						r.hasWordInHeader = item.Positions[i].Type == 'h'
-					}
+				r.includesWords++
+				if doc.WordCount > 0 {
+					r.tf_idf += float64(item.Count) / float64(doc.WordCount) * idf
+				}
+				r.bm25 += culcBM25(idf, float64(item.Count), doc, avgLen)
+				for i := 0; i < len(item.Positions) && !r.hasWordInHeader; i++ {
+					r.hasWordInHeader = item.Positions[i].Type == 'h'
 				}
 				rank[docID] = r
 				rankMu.Unlock()
@@ -144,86 +531,113 @@ func (s *Searcher) Search(query string, quorum float64, maxLen int) []*model.Doc
 		}(term)
 	}
 	
-	go func() {
-		wg.Wait()
-		close(errCh)
-	}()
-	
 	c, cancel := context.WithTimeout(context.Background(), 5 * time.Second)
 	defer cancel()
 	vec, err := s.vectorizer.Vectorize(query, c)
-	if err != nil {
-		log.Println(err)
-		return nil
+	cs.queryVec = vec
+	// vector retrieval runs while the term goroutines are still scoring lexical candidates
+	var vectorDocs []*model.Document
+	var vectorList []ranked
+	if err == nil && mode != RetrievalLexical {
+		vectorDocs, vectorList, err = s.semanticCandidates(vec)
 	}
-	
-	if err := <-errCh; err != nil {
-		return nil
+	wg.Wait()
+	if err != nil {
+		return nil, err
 	}
-	
-	filteredResult := make([]*model.Document, 0)
-	for _, doc := range result {
-		r := rank[doc.Id]
 
-		if r.tf_idf >= quorum {
-			sumCosW := 0.0
-			for _, v := range doc.WordVec {
-				sumCosW += calcCosineSimilarity(v, vec[0])
-			}
-			length := float64(len(doc.WordVec))
-			r.wordsCos = sumCosW / length
-			sumDistance := 0.0
-			for _, v := range doc.WordVec {
-				sumDistance += calcEuclidianDistance(v, vec[0])
+	// lexical scores are computed for every candidate, semantic ones may contain query terms too
+	filteredResult := make([]*model.Document, 0)
+	if mode != RetrievalSemantic {
+		for _, doc := range result {
+			if rank[doc.Id].tf_idf >= params.Quorum {
+				filteredResult = append(filteredResult, doc)
 			}
-			r.dpq = sumDistance / length
-			rank[doc.Id] = r
-			filteredResult = append(filteredResult, doc)
 		}
 	}
-
-	length = len(filteredResult)
-	if length == 0 {
-		return nil
+	if mode == RetrievalHybrid {
+		lexicalList := make([]ranked, 0, len(filteredResult))
+		for _, doc := range filteredResult {
+			lexicalList = append(lexicalList, ranked{id: doc.Id, score: rank[doc.Id].bm25})
+		}
+		sort.SliceStable(lexicalList, func(i, j int) bool {
+			return lexicalList[i].score > lexicalList[j].score
+		})
+		fused, err := fuse(params, lexicalList, vectorList)
+		if err != nil {
+			return nil, err
+		}
+		for id, score := range fused {
+			r := rank[id]
+			r.fusion = score
+			rank[id] = r
+		}
 	}
-
-	sort.Slice(filteredResult, func(i, j int) bool {
-		if TruncateToTwoDecimalPlaces(rank[filteredResult[i].Id].wordsCos) != TruncateToTwoDecimalPlaces(rank[filteredResult[j].Id].wordsCos) {
-			return rank[filteredResult[i].Id].wordsCos > rank[filteredResult[j].Id].wordsCos
+	included := make(map[[32]byte]struct{}, len(filteredResult))
+	for _, doc := range filteredResult {
+		included[doc.Id] = struct{}{}
+	}
+	for _, doc := range vectorDocs {
+		if _, ok := included[doc.Id]; !ok {
+			filteredResult = append(filteredResult, doc)
 		}
-		if TruncateToTwoDecimalPlaces(rank[filteredResult[i].Id].dpq) != TruncateToTwoDecimalPlaces(rank[filteredResult[j].Id].dpq) {
-			return rank[filteredResult[i].Id].dpq < rank[filteredResult[j].Id].dpq
+	}
+
+	for _, doc := range filteredResult {
+		if len(vec) == 0 {
+			break
 		}
-		if rank[filteredResult[i].Id].bm25 != rank[filteredResult[j].Id].bm25 {
-			return rank[filteredResult[i].Id].bm25 > rank[filteredResult[j].Id].bm25
+		// embeddings are stored apart from documents and read only for the final candidates
+		docVec, err := s.idx.GetEmbeddings(doc.Id)
+		if err != nil {
+			return nil, err
 		}
-		if rank[filteredResult[i].Id].includesWords != rank[filteredResult[j].Id].includesWords {
-			return rank[filteredResult[i].Id].includesWords > rank[filteredResult[j].Id].includesWords
+		if len(docVec) == 0 {
+			continue
 		}
-		if rank[filteredResult[i].Id].queryDencity != rank[filteredResult[j].Id].queryDencity {
-			return rank[filteredResult[i].Id].queryDencity > rank[filteredResult[j].Id].queryDencity
+		// a document is as close to the query as its best matching chunk
+		r := rank[doc.Id]
+		r.wordsCos, r.dpq = math.Inf(-1), math.Inf(1)
+		for i, v := range docVec {
+			if cos := calcCosineSimilarity(v, vec[0]); cos > r.wordsCos {
+				r.wordsCos, r.bestChunk = cos, i
+			}
+			r.dpq = min(r.dpq, calcEuclidianDistance(v, vec[0]))
 		}
-		return rank[filteredResult[i].Id].tf_idf > rank[filteredResult[j].Id].tf_idf
-	})
+		rank[doc.Id] = r
+	}
 
-	return filteredResult[:min(length, maxLen)]
+	// click feedback is looked up for the final candidates only
+	clickQuery := normalizeQuery(query)
+	for _, doc := range filteredResult {
+		r := rank[doc.Id]
+		r.clicks, r.queryClicks, r.dwell = s.clicks.features(clickQuery, doc.Id)
+		rank[doc.Id] = r
+	}
 
-	/*
-	// линейная модель ранжирования
-	
-	for i := range 10 {
-		condidates := map[uuid.UUID]requestRanking
-		for j := range 10 {
-			conId := filteredResult[10 * j + i].Id
-			condidates[conId] = rank[conId]
-		}
-		best, err := GetPredict(condidates)
-		if err != nil {
-			return filteredResult
+	cs.docs = filteredResult
+	return cs, nil
+}
+
+func (s *Searcher) semanticCandidates(queryVec [][]float64) ([]*model.Document, []ranked, error) {
+	if len(queryVec) == 0 {
+		return nil, nil, nil
+	}
+	matches, err := s.idx.SearchVectors(queryVec[0], 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	docs := make([]*model.Document, 0, len(matches))
+	list := make([]ranked, 0, len(matches))
+	for _, m := range matches {
+		doc, err := s.idx.GetDocumentByID(m.DocID)
+		if err != nil || doc == nil {
+			continue
 		}
-		filteredResult[best * 10 + i], filteredResult[best] = filteredResult[best], filteredResult[best * 10 + i]
+		docs = append(docs, doc)
+		list = append(list, ranked{id: m.DocID, score: m.Similarity})
 	}
-	*/
+	return docs, list, nil
 }
 
 func TruncateToTwoDecimalPlaces(f float64) float64 {
@@ -263,37 +677,36 @@ func calcEuclidianDistance(v1, v2 []float64) float64 {
 func culcBM25(idf float64, tf float64, doc *model.Document, avgLen float64) float64 {
 	k1 := 1.2
 	b := 0.75
+	if avgLen == 0 {
+		return 0
+	}
 	return idf * (tf * (k1 + 1)) / (tf + k1 * (1 - b + b * float64(doc.WordCount) / avgLen))
 }
 
-func calcQueryDencity(positions [][]model.Position, lenQuery int) int {
-	minDencity := math.MaxInt
-
-	bs := func(cur, target, l, r int) int {
-		for l < r {
-			m := (l + r) / 2
-			if positions[cur][m].I <= target {
-				l = m + 1
-			}
-			if positions[cur][m].I > target {
-				r = m
-			}
-		}
-		return l
+// calcQueryDencity finds the shortest window of positions containing every matched term
+// and returns the share of that window occupied by the terms.
+func calcQueryDencity(positions [][]model.Position) float64 {
+	if len(positions) == 0 {
+		return 0.0
 	}
 
-	for i := range positions[0] {
-		cur := positions[0][i].I
-		last := cur
-		for j := range lenQuery {
-			position := bs(i, last, 0, len(positions[j]))
-			if position == len(positions[j]) {
-				return minDencity
+	cursors := make([]int, len(positions))
+	minSpan := math.MaxInt
+	for {
+		lo, hi, loList := math.MaxInt, math.MinInt, 0
+		for i, list := range positions {
+			p := list[cursors[i]].I
+			if p < lo {
+				lo, loList = p, i
 			}
-			last = position
+			hi = max(hi, p)
+		}
+		minSpan = min(minSpan, hi - lo)
+		cursors[loList]++
+		if cursors[loList] == len(positions[loList]) {
+			break
 		}
-		minDencity = min(minDencity, last - cur)
 	}
 
-	return minDencity
+	return float64(len(positions)) / float64(minSpan + 1)
 }
\ No newline at end of file
@@ -0,0 +1,144 @@
+package searcher
+
+import (
+	"cmp"
+	"strings"
+	"time"
+
+	"github.com/box1bs/monocle/internal/model"
+)
+
+// LiteralPrefix in front of a query turns spelling correction off for it.
+const LiteralPrefix = "!"
+
+// fewHits is the number of hits below which the corrected query is searched as well.
+const fewHits = 5
+
+// Results are the hits of a query together with its spelling correction. Corrected is the query
+// with every unknown word replaced by its best suggestion, "" when there was nothing to correct
+// or the query was literal. Hits of the corrected query follow those of the original one.
+type Results struct {
+	Query 		string
+	Corrected 	string
+	Literal 	bool
+	Hits 		[]Hit
+}
+
+// SearchCorrected searches query as SearchWith does and reports its spelling correction.
+// When the query finds fewer than fewHits documents the corrected query is searched too and
+// its hits are appended to the original ones. A query starting with LiteralPrefix is searched as is.
+// Queries that found documents are remembered for Suggest, misspelled ones as corrected,
+// and every query is written to the query log.
+func (s *Searcher) SearchCorrected(query string, params Params) (*Results, error) {
+	start := time.Now()
+	res, terms, err := s.searchCorrected(query, params)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Hits) > 0 {
+		s.recordQuery(cmp.Or(res.Corrected, res.Query))
+	}
+	s.logQuery(start, res, terms)
+	return res, nil
+}
+
+func (s *Searcher) searchCorrected(query string, params Params) (*Results, []model.QueryTerm, error) {
+	res := &Results{Query: query}
+	if literal, ok := strings.CutPrefix(query, LiteralPrefix); ok {
+		res.Query, res.Literal = strings.TrimSpace(literal), true
+	}
+	terms, err := s.idx.AnalyzeQuery(res.Query, params.Language)
+	if err != nil {
+		return nil, nil, err
+	}
+	hits, _, err := s.search(res.Query, terms, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	res.Hits = hits
+	if res.Literal {
+		return res, terms, nil
+	}
+	if res.Corrected = correctQuery(res.Query, terms); res.Corrected == "" || len(hits) >= fewHits {
+		return res, terms, nil
+	}
+
+	// the corrected words are analyzed with the query they are part of
+	corrected, err := s.SearchWith(res.Corrected, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	seen := make(map[[32]byte]struct{}, len(hits))
+	for _, hit := range hits {
+		seen[hit.Doc.Id] = struct{}{}
+	}
+	for _, hit := range corrected {
+		if len(res.Hits) >= params.MaxLen {
+			break
+		}
+		if _, ok := seen[hit.Doc.Id]; !ok {
+			hit.Corrected = true
+			markCorrections(hit.Explanation, terms)
+			res.Hits = append(res.Hits, hit)
+		}
+	}
+	return res, terms, nil
+}
+
+// markCorrections sets CorrectedFrom on the terms of an explanation of the corrected query
+// that replaced a word of the original one.
+func markCorrections(e *Explanation, original []model.QueryTerm) {
+	if e == nil {
+		return
+	}
+	typed := map[string]string{}
+	for _, term := range original {
+		if len(term.Suggestions) > 0 {
+			typed[term.Suggestions[0]] = term.Text
+		}
+	}
+	for i := range e.Terms {
+		if from, ok := typed[e.Terms[i].Text]; ok {
+			e.Terms[i].CorrectedFrom = from
+		}
+	}
+}
+
+// DidYouMean returns query with every word the index does not know replaced by its best
+// spelling suggestion, or "" when there is nothing to correct. The query itself is never rewritten.
+func (s *Searcher) DidYouMean(query string, params Params) (string, error) {
+	terms, err := s.idx.AnalyzeQuery(query, params.Language)
+	if err != nil {
+		return "", err
+	}
+	return correctQuery(query, terms), nil
+}
+
+// correctQuery replaces the words of terms in query in order, so a word repeated in the query
+// is matched with the term it was analyzed into.
+func correctQuery(query string, terms []model.QueryTerm) string {
+	var b strings.Builder
+	rest, changed := query, false
+	for _, term := range terms {
+		if term.Text == "" {
+			continue
+		}
+		i := strings.Index(rest, term.Text)
+		if i < 0 {
+			continue
+		}
+		b.WriteString(rest[:i])
+		if len(term.Suggestions) > 0 {
+			b.WriteString(term.Suggestions[0])
+			changed = true
+		} else {
+			b.WriteString(term.Text)
+		}
+		rest = rest[i + len(term.Text):]
+	}
+	if !changed {
+		return ""
+	}
+	b.WriteString(rest)
+	return b.String()
+}
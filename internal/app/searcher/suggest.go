@@ -0,0 +1,119 @@
+package searcher
+
+import (
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/box1bs/monocle/pkg/trie"
+)
+
+// queryWeight is the number of documents a past search counts as when past queries
+// are ranked against dictionary words.
+const queryWeight = 10
+
+// Completion is a suggested continuation of a typed prefix, either a past query or the prefix
+// with its last word completed from the dictionary. Weight is the document frequency of the word
+// plus queryWeight for every time the query was searched.
+type Completion struct {
+	Text 	string 	`json:"text"`
+	Weight 	int 	`json:"weight"`
+	Query 	bool 	`json:"query,omitempty"`
+}
+
+// LoadSuggestions fills the completion indexes with the indexed words and the past queries.
+func (s *Searcher) LoadSuggestions() error {
+	words, queries := trie.New(), trie.New()
+	if err := s.idx.Dictionary(words.Add); err != nil {
+		return err
+	}
+	if err := s.idx.LoadQueryCounts(queries.Add); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.words, s.queries = words, queries
+	s.mu.Unlock()
+	return nil
+}
+
+// Suggest returns up to n completions of prefix, heaviest first. Past queries starting with the
+// prefix compete with the prefix whose last word is completed by a dictionary word, when the
+// dictionary has too few words starting with it words containing it are taken from the n-gram index.
+func (s *Searcher) Suggest(prefix string, n int) ([]Completion, error) {
+	s.mu.RLock()
+	words, queries := s.words, s.queries
+	s.mu.RUnlock()
+
+	typed := normalizeQuery(prefix)
+	if typed == "" || n <= 0 {
+		return nil, nil
+	}
+	byText := map[string]*Completion{}
+	add := func(text string, weight int, query bool) {
+		if c, ok := byText[text]; ok {
+			c.Weight += weight
+			c.Query = c.Query || query
+			return
+		}
+		byText[text] = &Completion{Text: text, Weight: weight, Query: query}
+	}
+
+	for _, e := range queries.TopK(typed, n) {
+		add(e.Key, e.Weight * queryWeight, true)
+	}
+	// a trailing space means the last word is finished, only past queries can go on from there
+	if !strings.HasSuffix(prefix, " ") {
+		head, last := "", typed
+		if i := strings.LastIndexByte(typed, ' '); i >= 0 {
+			head, last = typed[:i + 1], typed[i + 1:]
+		}
+		completed := words.TopK(last, n)
+		for _, e := range completed {
+			add(head + e.Key, e.Weight, false)
+		}
+		if len(completed) < n {
+			infix, err := s.idx.WordsContaining(last)
+			if err != nil {
+				return nil, err
+			}
+			for _, word := range infix {
+				if strings.HasPrefix(word, last) {
+					continue
+				}
+				df, _ := words.Weight(word)
+				add(head + word, max(df, 1), false)
+			}
+		}
+	}
+
+	out := make([]Completion, 0, len(byText))
+	for _, c := range byText {
+		out = append(out, *c)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Weight != out[j].Weight {
+			return out[i].Weight > out[j].Weight
+		}
+		return out[i].Text < out[j].Text
+	})
+	return out[:min(n, len(out))], nil
+}
+
+// recordQuery counts a query that found documents towards the past queries suggested.
+func (s *Searcher) recordQuery(query string) {
+	query = normalizeQuery(query)
+	if query == "" {
+		return
+	}
+	s.mu.RLock()
+	s.queries.Add(query, 1)
+	s.mu.RUnlock()
+	if err := s.idx.CountQuery(query); err != nil {
+		log.Println(err)
+	}
+}
+
+// normalizeQuery lowercases query and collapses its white space.
+func normalizeQuery(query string) string {
+	return strings.Join(strings.Fields(strings.ToLower(query)), " ")
+}
@@ -0,0 +1,150 @@
+package searcher
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/box1bs/monocle/internal/model"
+)
+
+// fakeIndex serves documents, the dictionary, n-gram lookups, query counts and the generation,
+// the rest of index is left nil.
+type fakeIndex struct {
+	index
+	docs 		map[[32]byte]*model.Document
+	dict 		map[string]int
+	queries 	map[string]int
+	containing 	map[string][]string
+	counted 	[]string
+	generation 	uint64
+}
+
+func (f *fakeIndex) GetDocumentByID(id [32]byte) (*model.Document, error) {
+	if doc, ok := f.docs[id]; ok {
+		return doc, nil
+	}
+	return nil, model.ErrNotFound
+}
+
+func (f *fakeIndex) Dictionary(add func(string, int)) error {
+	for word, df := range f.dict {
+		add(word, df)
+	}
+	return nil
+}
+
+func (f *fakeIndex) LoadQueryCounts(add func(string, int)) error {
+	for query, n := range f.queries {
+		add(query, n)
+	}
+	return nil
+}
+
+func (f *fakeIndex) WordsContaining(fragment string) ([]string, error) {
+	return f.containing[fragment], nil
+}
+
+func (f *fakeIndex) CountQuery(query string) error {
+	f.counted = append(f.counted, query)
+	return nil
+}
+
+func (f *fakeIndex) Generation() uint64 {
+	return f.generation
+}
+
+func newSuggester(t *testing.T, idx *fakeIndex) *Searcher {
+	t.Helper()
+	s := NewSearcher(idx, nil)
+	if err := s.LoadSuggestions(); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestSuggest(t *testing.T) {
+	s := newSuggester(t, &fakeIndex{
+		dict: map[string]int{"search": 5, "seal": 8, "season": 1, "archive": 2},
+		queries: map[string]int{"search engine": 1, "seal": 1},
+		containing: map[string][]string{"arch": {"search", "archive", "starch"}},
+	})
+	tests := []struct {
+		name 	string
+		prefix 	string
+		n 		int
+		want 	[]Completion
+	}{
+		{
+			// seal is both a word and a past query, its weights add up
+			name: "words weighted by df and queries",
+			prefix: "sea",
+			n: 4,
+			want: []Completion{{"seal", 18, true}, {"search engine", 10, true}, {"search", 5, false}, {"season", 1, false}},
+		},
+		{
+			name: "cut at n",
+			prefix: "Sea",
+			n: 1,
+			want: []Completion{{"seal", 18, true}},
+		},
+		{
+			name: "last word completed",
+			prefix: "go  sea",
+			n: 2,
+			want: []Completion{{"go seal", 8, false}, {"go search", 5, false}},
+		},
+		{
+			name: "finished word only continues queries",
+			prefix: "search ",
+			n: 5,
+			want: []Completion{{"search engine", 10, true}},
+		},
+		{
+			// archive starts with the prefix and comes from the dictionary, the infix words
+			// weigh their df or 1 when the dictionary lacks them
+			name: "infix words when prefixes run out",
+			prefix: "arch",
+			n: 5,
+			want: []Completion{{"search", 5, false}, {"archive", 2, false}, {"starch", 1, false}},
+		},
+		{
+			name: "blank prefix",
+			prefix: "  ",
+			n: 5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Suggest(tt.prefix, tt.n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Suggest(%q, %d) = %v, want %v", tt.prefix, tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordQuery(t *testing.T) {
+	idx := &fakeIndex{dict: map[string]int{"golang": 3}}
+	s := newSuggester(t, idx)
+	s.recordQuery("  Golang   Generics ")
+	s.recordQuery("golang generics")
+	s.recordQuery(" ")
+
+	if !reflect.DeepEqual(idx.counted, []string{"golang generics", "golang generics"}) {
+		t.Fatalf("counted queries %q", idx.counted)
+	}
+	got, err := s.Suggest("gol", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Completion{{"golang generics", 2 * queryWeight, true}, {"golang", 3, false}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Suggest(gol) = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,276 @@
+package server
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/box1bs/monocle/internal/app/searcher"
+	"github.com/box1bs/monocle/internal/model"
+)
+
+type engine interface {
+	SearchCorrected(string, searcher.Params) (*searcher.Results, error)
+	ExplainWith(string, [32]byte, searcher.Params) (*searcher.Explanation, error)
+	Suggest(string, int) ([]searcher.Completion, error)
+	Feedback(string, [32]byte, int, time.Duration) error
+}
+
+type Server struct {
+	srv 	*http.Server
+	engine 	engine
+}
+
+type result struct {
+	ID 			string 					`json:"id"`
+	URL 		string 					`json:"url"`
+	Score 		float64 				`json:"score"`
+	Signals 	[]searcher.SignalScore 	`json:"signals,omitempty"`
+	Passage 	*searcher.Passage 		`json:"passage,omitempty"`
+	Explanation *searcher.Explanation 	`json:"explanation,omitempty"`
+	Corrected 	bool 					`json:"corrected,omitempty"`
+}
+
+// searchResponse carries the spelling corrected query in did_you_mean, results only the
+// corrected query found are marked corrected. A query starting with ! is never corrected.
+type searchResponse struct {
+	Query 		string 		`json:"query"`
+	DidYouMean 	string 		`json:"did_you_mean,omitempty"`
+	Literal 	bool 		`json:"literal,omitempty"`
+	Results 	[]result 	`json:"results"`
+}
+
+func NewServer(port int, e engine) *Server {
+	s := &Server{engine: e}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /search", s.handleSearch)
+	mux.HandleFunc("GET /explain", s.handleExplain)
+	mux.HandleFunc("GET /suggest", s.handleSuggest)
+	mux.HandleFunc("POST /feedback", s.handleFeedback)
+	s.srv = &http.Server{
+		Addr: 				fmt.Sprintf(":%d", port),
+		Handler: 			mux,
+		ReadHeaderTimeout: 	5 * time.Second,
+	}
+	return s
+}
+
+func (s *Server) Run() error {
+	if err := s.srv.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.srv.Shutdown(ctx)
+}
+
+// params reads the ranking options shared by /search and /explain
+func params(r *http.Request) (searcher.Params, error) {
+	q := r.URL.Query()
+	p := searcher.Params{
+		Quorum: 	searcher.DefaultQuorum,
+		MaxLen: 	20,
+		Pipeline: 	q.Get("pipeline"),
+		Retrieval: 	q.Get("retrieval"),
+		Fusion: 	q.Get("fusion"),
+		Language: 	q.Get("lang"),
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return p, fmt.Errorf("invalid limit: %q", v)
+		}
+		p.MaxLen = limit
+	}
+	if v := q.Get("quorum"); v != "" {
+		quorum, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return p, fmt.Errorf("invalid quorum: %q", v)
+		}
+		p.Quorum = quorum
+	}
+	if v := q.Get("rrf_k"); v != "" {
+		k, err := strconv.Atoi(v)
+		if err != nil || k <= 0 {
+			return p, fmt.Errorf("invalid rrf_k: %q", v)
+		}
+		p.RRFK = k
+	}
+	if v := q.Get("vector_weight"); v != "" {
+		w, err := strconv.ParseFloat(v, 64)
+		if err != nil || w <= 0 || w > 1 {
+			return p, fmt.Errorf("invalid vector_weight: %q", v)
+		}
+		p.VectorWeight = w
+	}
+	if v := q.Get("explain"); v != "" {
+		explain, err := strconv.ParseBool(v)
+		if err != nil {
+			return p, fmt.Errorf("invalid explain flag: %q", v)
+		}
+		p.Explain = explain
+	}
+	return p, nil
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		writeError(w, http.StatusBadRequest, "missing q parameter")
+		return
+	}
+	p, err := params(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	found, err := s.engine.SearchCorrected(query, p)
+	if errors.Is(err, model.ErrInvalid) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	} else if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := searchResponse{Query: found.Query, DidYouMean: found.Corrected, Literal: found.Literal, Results: make([]result, 0, len(found.Hits))}
+	for _, hit := range found.Hits {
+		res := result{
+			ID: 			hex.EncodeToString(hit.Doc.Id[:]),
+			URL: 			hit.Doc.URL,
+			Score: 			hit.Score,
+			Passage: 		hit.Passage,
+			Explanation: 	hit.Explanation,
+			Corrected: 		hit.Corrected,
+		}
+		if p.Explain {
+			res.Signals = hit.Signals
+		}
+		resp.Results = append(resp.Results, res)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleExplain(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		writeError(w, http.StatusBadRequest, "missing q parameter")
+		return
+	}
+	id, err := hex.DecodeString(r.URL.Query().Get("id"))
+	if err != nil || len(id) != 32 {
+		writeError(w, http.StatusBadRequest, "id must be a hex encoded 32 byte document id")
+		return
+	}
+	p, err := params(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	e, err := s.engine.ExplainWith(query, [32]byte(id), p)
+	if errors.Is(err, model.ErrNotFound) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	} else if errors.Is(err, model.ErrInvalid) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	} else if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, e)
+}
+
+type suggestResponse struct {
+	Prefix 		string 					`json:"prefix"`
+	Suggestions []searcher.Completion 	`json:"suggestions"`
+}
+
+func (s *Server) handleSuggest(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("q")
+	if prefix == "" {
+		writeError(w, http.StatusBadRequest, "missing q parameter")
+		return
+	}
+	n := 10
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid limit: %q", v))
+			return
+		}
+		n = limit
+	}
+
+	completions, err := s.engine.Suggest(prefix, n)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if completions == nil {
+		completions = []searcher.Completion{}
+	}
+	writeJSON(w, http.StatusOK, suggestResponse{Prefix: prefix, Suggestions: completions})
+}
+
+// feedbackRequest reports that the result id was opened from the results of query at position,
+// 1-based, and read for dwell_ms milliseconds, 0 when unknown.
+type feedbackRequest struct {
+	Query 		string 	`json:"query"`
+	ID 			string 	`json:"id"`
+	Position 	int 	`json:"position"`
+	DwellMS 	int64 	`json:"dwell_ms"`
+}
+
+func (s *Server) handleFeedback(w http.ResponseWriter, r *http.Request) {
+	var req feedbackRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1 << 16)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid feedback body: " + err.Error())
+		return
+	}
+	if req.Query == "" {
+		writeError(w, http.StatusBadRequest, "missing query")
+		return
+	}
+	id, err := hex.DecodeString(req.ID)
+	if err != nil || len(id) != 32 {
+		writeError(w, http.StatusBadRequest, "id must be a hex encoded 32 byte document id")
+		return
+	}
+	if req.Position < 0 || req.DwellMS < 0 {
+		writeError(w, http.StatusBadRequest, "position and dwell_ms must not be negative")
+		return
+	}
+
+	err = s.engine.Feedback(req.Query, [32]byte(id), req.Position, time.Duration(req.DwellMS) * time.Millisecond)
+	if errors.Is(err, model.ErrNotFound) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	} else if errors.Is(err, model.ErrInvalid) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	} else if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
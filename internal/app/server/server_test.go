@@ -0,0 +1,91 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/box1bs/monocle/internal/app/searcher"
+	"github.com/box1bs/monocle/internal/model"
+)
+
+// fakeEngine answers every call with err.
+type fakeEngine struct {
+	err error
+}
+
+func (e *fakeEngine) SearchCorrected(query string, p searcher.Params) (*searcher.Results, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	return &searcher.Results{Query: query}, nil
+}
+
+func (e *fakeEngine) ExplainWith(string, [32]byte, searcher.Params) (*searcher.Explanation, error) {
+	return &searcher.Explanation{}, e.err
+}
+
+func (e *fakeEngine) Suggest(string, int) ([]searcher.Completion, error) {
+	return nil, e.err
+}
+
+func (e *fakeEngine) Feedback(string, [32]byte, int, time.Duration) error {
+	return e.err
+}
+
+func serve(t *testing.T, e engine, method, target, body string) int {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	NewServer(0, e).srv.Handler.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return rec.Code
+}
+
+func TestFeedbackStatus(t *testing.T) {
+	id := strings.Repeat("ab", 32)
+	tests := []struct {
+		name 	string
+		body 	string
+		err 	error
+		want 	int
+	}{
+		{"recorded", `{"query": "go", "id": "` + id + `"}`, nil, http.StatusNoContent},
+		{"unknown document", `{"query": "go", "id": "` + id + `"}`, fmt.Errorf("document: %w", model.ErrNotFound), http.StatusNotFound},
+		{"empty query", `{"query": "!", "id": "` + id + `"}`, fmt.Errorf("empty query: %w", model.ErrInvalid), http.StatusBadRequest},
+		{"storage failure", `{"query": "go", "id": "` + id + `"}`, errors.New("badger: write failed"), http.StatusInternalServerError},
+		{"bad id", `{"query": "go", "id": "zz"}`, nil, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := serve(t, &fakeEngine{err: tt.err}, http.MethodPost, "/feedback", tt.body); got != tt.want {
+				t.Fatalf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchStatus(t *testing.T) {
+	tests := []struct {
+		name 	string
+		target 	string
+		err 	error
+		want 	int
+	}{
+		{"found", "/search?q=go", nil, http.StatusOK},
+		{"missing q", "/search", nil, http.StatusBadRequest},
+		{"vector weight above 1", "/search?q=go&vector_weight=1.5", nil, http.StatusBadRequest},
+		{"unknown pipeline", "/search?q=go&pipeline=linear", fmt.Errorf("unknown ranking pipeline: %w", model.ErrInvalid), http.StatusBadRequest},
+		{"storage failure", "/search?q=go", errors.New("badger: read failed"), http.StatusInternalServerError},
+		{"explain unknown retrieval", "/explain?q=go&id=" + strings.Repeat("ab", 32) + "&retrieval=dense", fmt.Errorf("unknown retrieval mode: %w", model.ErrInvalid), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := serve(t, &fakeEngine{err: tt.err}, http.MethodGet, tt.target, ""); got != tt.want {
+				t.Fatalf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -31,12 +31,12 @@ Features:
 	- REST API for remote control and search functionality
 
 Usage of ./saturday:
-  	-cli
-        Run in CLI mode instead of REST server
   	-config string
         Path to configuration file (default "configs/search_config.json")
   	-log string
         Path to log file (default "logs/indexedURLs.txt")
+  	-serve
+        Serve search over REST instead of the interactive CLI
   	-srv-port int
     	REST server port (default 50051)
 
@@ -1,10 +1,25 @@
 package model
 
+import "errors"
+
+// ErrNotFound is returned, wrapped, for documents the index does not hold.
+// ErrInvalid is returned, wrapped, for requests that can't be served as given, like an empty query.
+var (
+	ErrNotFound = errors.New("not found")
+	ErrInvalid = errors.New("invalid request")
+)
+
+// WordVec, Text and Chunks are kept out of the document record, repositories store them
+// separately and documents read back from an index come without them.
+// Chunks[i] is the part of Text WordVec[i] was computed from.
 type Document struct {
 	Id 				[32]byte	`json:"id"`
 	URL				string		`json:"url"`
 	WordCount 		int			`json:"words_count"`
-	WordVec 		[][]float64	`json:"word_vec"`
+	Lang 			string 		`json:"lang,omitempty"`
+	WordVec 		[][]float64	`json:"-"`
+	Text 			string 		`json:"-"`
+	Chunks 			[]Chunk 	`json:"-"`
 }
 
 const (
@@ -47,4 +62,27 @@ func NewTypeTextObj[T Passage | Position](t byte, text string, i int) T {
 	default:
 		panic("unnamed passage type")
 	}
-}
\ No newline at end of file
+}
+
+// Chunk is the byte range of document text one embedding was computed from.
+type Chunk struct {
+	Start 	int
+	End 	int
+}
+
+// CorpusStats are the collection wide counts ranking needs: Docs documents of Tokens words in total,
+// Fields positions per passage type and DF documents per term id, for the terms asked for.
+type CorpusStats struct {
+	Docs 	int
+	Tokens 	int
+	Fields 	map[byte]int
+	DF 		map[int]int
+}
+
+// AvgLen is the mean document length in words, 0 for an empty index.
+func (cs *CorpusStats) AvgLen() float64 {
+	if cs.Docs == 0 {
+		return 0
+	}
+	return float64(cs.Tokens) / float64(cs.Docs)
+}
@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// Click is a result opened from the results of Query, Position is its 1-based rank
+// and Dwell the time spent on it, zero when the client could not tell.
+type Click struct {
+	Time 		time.Time 		`json:"time"`
+	Query 		string 			`json:"query"`
+	DocID 		[32]byte 		`json:"doc_id"`
+	Position 	int 			`json:"position"`
+	Dwell 		time.Duration 	`json:"dwell"`
+}
+
+// Popularity sums up the clicks on a document, Dwell is their total dwell time.
+type Popularity struct {
+	Clicks 	int 			`json:"clicks"`
+	Dwell 	time.Duration 	`json:"dwell"`
+}
+
+func (p Popularity) Add(c Click) Popularity {
+	return Popularity{Clicks: p.Clicks + 1, Dwell: p.Dwell + c.Dwell}
+}
@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// QueryTerm is a query word after analysis, Text is the word as it appears in the query.
+// Suggestions are the dictionary words the spell checker proposes when Term is not indexed,
+// best first. Terms at the same Pos are alternatives, like a word and its synonyms,
+// Weight scales the term's score. A synonym of several words is one term with the ids of
+// its words in Phrase and a negative ID, it matches where the words follow one another.
+type QueryTerm struct {
+	Term 		string 		`json:"term"`
+	Text 		string 		`json:"text"`
+	Suggestions []string 	`json:"suggestions,omitempty"`
+	ID 			int 		`json:"id"`
+	Phrase 		[]int 		`json:"phrase,omitempty"`
+	Pos 		int 	`json:"pos"`
+	Weight 		float64 `json:"weight"`
+}
+
+// VectorMatch is a document found by embedding similarity, Chunk is the index
+// of its best matching embedding.
+type VectorMatch struct {
+	DocID 		[32]byte
+	Chunk 		int
+	Similarity 	float64
+}
+
+// QueryLogEntry records one search: the query as typed, its analyzed terms, the corrections
+// proposed for misspelled words, how many documents it found, how long it took and the URLs
+// of its best results.
+type QueryLogEntry struct {
+	Time 		time.Time 			`json:"time"`
+	Query 		string 				`json:"query"`
+	Terms 		[]string 			`json:"terms"`
+	Corrections map[string]string 	`json:"corrections,omitempty"`
+	Corrected 	string 				`json:"corrected,omitempty"`
+	Results 	int 				`json:"results"`
+	Latency 	time.Duration 		`json:"latency"`
+	Top 		[]string 			`json:"top,omitempty"`
+}
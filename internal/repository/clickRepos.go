@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/box1bs/monocle/internal/model"
+	"github.com/dgraph-io/badger/v3"
+)
+
+const (
+	// ClickKeyPrefix keys reported clicks: click:<time key> holds a json encoded click.
+	ClickKeyPrefix = "click:"
+	// ClickStatsKeyPrefix keys click popularity: clickstats:<doc id><query> holds the json encoded
+	// popularity of the document in the results of the query.
+	ClickStatsKeyPrefix = "clickstats:"
+)
+
+// SaveClick stores a click and adds it to the popularity of its document for its query in one transaction.
+func (ir *IndexRepository) SaveClick(c *model.Click) error {
+	val, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	ir.mu.Lock()
+	defer ir.mu.Unlock()
+	return ir.update(func(txn *badger.Txn) error {
+		if err := txn.Set(ir.timeKey(ClickKeyPrefix, c.Time), val); err != nil {
+			return err
+		}
+		key := []byte(ClickStatsKeyPrefix + string(c.DocID[:]) + c.Query)
+		var p model.Popularity
+		item, err := txn.Get(key)
+		if err == nil {
+			if err := item.Value(func(val []byte) error {
+				return json.Unmarshal(val, &p)
+			}); err != nil {
+				return err
+			}
+		} else if err != badger.ErrKeyNotFound {
+			return err
+		}
+		encoded, err := json.Marshal(p.Add(*c))
+		if err != nil {
+			return err
+		}
+		return txn.Set(key, encoded)
+	})
+}
+
+// Clicks returns the clicks reported at or after since, oldest first.
+func (ir *IndexRepository) Clicks(since time.Time) ([]model.Click, error) {
+	clicks := []model.Click{}
+	err := ir.DB.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		prefix := []byte(ClickKeyPrefix)
+		for it.Seek(timePrefix(ClickKeyPrefix, since)); it.ValidForPrefix(prefix); it.Next() {
+			var c model.Click
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &c)
+			}); err != nil {
+				return err
+			}
+			clicks = append(clicks, c)
+		}
+		return nil
+	})
+	return clicks, err
+}
+
+// LoadClickStats calls add with the popularity of every clicked document per query.
+func (ir *IndexRepository) LoadClickStats(add func(query string, docID [32]byte, p model.Popularity)) error {
+	return ir.DB.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		prefix := []byte(ClickStatsKeyPrefix)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			key := it.Item().Key()[len(prefix):]
+			if len(key) < 32 {
+				continue
+			}
+			var p model.Popularity
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &p)
+			}); err != nil {
+				return err
+			}
+			add(string(key[32:]), [32]byte(key[:32]), p)
+		}
+		return nil
+	})
+}
@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"context"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -13,6 +15,7 @@ import (
 
 const (
     DocumentKeyPrefix = "doc:"
+    ChunksKeyPrefix = "chunks:"
     WordDocumentKeyFormat = "%d_%s_%d"
 )
 
@@ -25,7 +28,7 @@ func (ir *IndexRepository) bytesToDocument(body []byte) (*model.Document, error)
 		Id 				[]byte 		`json:"id"`
 		URL 			string 		`json:"url"`
 		WordCount 		int 		`json:"words_count"`
-		Vec				[][]float64 `json:"word_vec"`
+		Lang 			string 		`json:"lang"`
 	}
 	err := json.Unmarshal(body, &payload)
 	if err != nil {
@@ -39,7 +42,7 @@ func (ir *IndexRepository) bytesToDocument(body []byte) (*model.Document, error)
 		Id: b,
 		URL: payload.URL,
 		WordCount: payload.WordCount,
-		WordVec: payload.Vec,
+		Lang: payload.Lang,
 	}
 	return doc, err
 }
@@ -53,19 +56,79 @@ func (ir *IndexRepository) SaveDocument(doc *model.Document) error {
 	ir.mu.Lock()
 	defer ir.mu.Unlock()
 
-	return ir.DB.Update(func(txn *badger.Txn) error {
+	put, err := ir.documentWriter(doc, docBytes)
+	if err != nil {
+		return err
+	}
+	return ir.update(put)
+}
+
+// IndexDocument saves doc with the postings of its words in one transaction, so the corpus
+// statistics never count a document without its terms or terms without their document.
+func (ir *IndexRepository) IndexDocument(c context.Context, doc *model.Document, sequence []int, positions map[int][]model.Position) error {
+	docBytes, err := ir.documentToBytes(doc)
+	if err != nil {
+		return err
+	}
+
+	ir.mu.Lock()
+	defer ir.mu.Unlock()
+
+	put, err := ir.documentWriter(doc, docBytes)
+	if err != nil {
+		return err
+	}
+	return ir.update(func(txn *badger.Txn) error {
+		if err := putPostings(c, txn, doc.Id, sequence, positions); err != nil {
+			return err
+		}
+		return put(txn)
+	})
+}
+
+// documentWriter encodes the embeddings and chunks of doc and returns the writes of the document
+// with its length counted in, ir.mu must be held.
+func (ir *IndexRepository) documentWriter(doc *model.Document, docBytes []byte) (func(txn *badger.Txn) error, error) {
+	var (
+		vecBytes 	[]byte
+		err 		error
+	)
+	if len(doc.WordVec) > 0 {
+		if vecBytes, err = encodeEmbeddings(doc.WordVec, ir.encoding); err != nil {
+			return nil, err
+		}
+	}
+	var chunkBytes []byte
+	if len(doc.Chunks) > 0 {
+		chunkBytes = encodeChunks(doc.Text, doc.Chunks)
+	}
+
+	return func(txn *badger.Txn) error {
+		if err := ir.countDocument(txn, doc); err != nil {
+			return err
+		}
 		if err := txn.Set([]byte("doc:" + string(doc.Id[:])), docBytes); err != nil {
 			return err
 		}
+		if vecBytes != nil {
+			if err := txn.Set([]byte(EmbeddingKeyPrefix + string(doc.Id[:])), vecBytes); err != nil {
+				return err
+			}
+		}
+		if chunkBytes != nil {
+			return txn.Set([]byte(ChunksKeyPrefix + string(doc.Id[:])), chunkBytes)
+		}
 		return nil
-	})
+	}, nil
 }
 
 func (ir *IndexRepository) GetDocumentByID(docID [32]byte) (*model.Document, error) {
 	var docBytes []byte
 	err := ir.DB.View(func(txn *badger.Txn) error {
 		item, err := txn.Get([]byte("doc:" + string(docID[:])))
-		if err != nil {
+		if err == badger.ErrKeyNotFound {
+			return fmt.Errorf("document %x: %w", docID, model.ErrNotFound)
+		} else if err != nil {
 			return err
 		}
 
@@ -82,6 +145,59 @@ func (ir *IndexRepository) GetDocumentByID(docID [32]byte) (*model.Document, err
 	return ir.bytesToDocument(docBytes)
 }
 
+// GetChunks returns the document text with the spans its embeddings were computed from,
+// documents indexed before chunks were stored have none.
+func (ir *IndexRepository) GetChunks(docID [32]byte) (string, []model.Chunk, error) {
+	var (
+		text 	string
+		chunks 	[]model.Chunk
+	)
+	err := ir.DB.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(ChunksKeyPrefix + string(docID[:])))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			text, chunks, err = decodeChunks(val)
+			return err
+		})
+	})
+	return text, chunks, err
+}
+
+// encodeChunks layout: chunk count, start and end offset of every chunk, then the text.
+func encodeChunks(text string, chunks []model.Chunk) []byte {
+	buf := make([]byte, 0, 4 + 8 * len(chunks) + len(text))
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(chunks)))
+	for _, c := range chunks {
+		buf = binary.BigEndian.AppendUint32(buf, uint32(c.Start))
+		buf = binary.BigEndian.AppendUint32(buf, uint32(c.End))
+	}
+	return append(buf, text...)
+}
+
+func decodeChunks(data []byte) (string, []model.Chunk, error) {
+	if len(data) < 4 {
+		return "", nil, errors.New("corrupted chunks record")
+	}
+	count := int(binary.BigEndian.Uint32(data))
+	if len(data) < 4 + 8 * count {
+		return "", nil, errors.New("corrupted chunks record")
+	}
+	text := string(data[4 + 8 * count:])
+	chunks := make([]model.Chunk, count)
+	for i := range chunks {
+		off := 4 + 8 * i
+		chunks[i] = model.Chunk{Start: int(binary.BigEndian.Uint32(data[off:])), End: int(binary.BigEndian.Uint32(data[off + 4:]))}
+		if chunks[i].Start > chunks[i].End || chunks[i].End > len(text) {
+			return "", nil, errors.New("corrupted chunks record")
+		}
+	}
+	return text, chunks, nil
+}
+
 func (ir *IndexRepository) GetAllDocuments() ([]*model.Document, error) {
 	var documents []*model.Document
 	
@@ -122,29 +238,14 @@ func (ir *IndexRepository) GetAllDocuments() ([]*model.Document, error) {
 	return documents, nil
 }
 
+// GetDocumentsCount reads the document count kept with the corpus statistics.
 func (ir *IndexRepository) GetDocumentsCount() (int, error) {
 	var count int
-	
-	err := ir.DB.View(func(txn *badger.Txn) error {
-		docPrefix := []byte(DocumentKeyPrefix)
-		
-		opts := badger.DefaultIteratorOptions
-		opts.PrefetchValues = false
-		it := txn.NewIterator(opts)
-		defer it.Close()
-		
-		for it.Seek(docPrefix); it.ValidForPrefix(docPrefix); it.Next() {
-			count++
-		}
-		
-		return nil
+	return count, ir.DB.View(func(txn *badger.Txn) error {
+		var err error
+		count, err = getCount(txn, []byte(statsDocsKey))
+		return err
 	})
-	
-	if err != nil {
-		return 0, err
-	}
-	
-	return count, nil
 }
 
 func (ir *IndexRepository) CheckContent(id [32]byte, hash [32]byte) (bool, *model.Document, error) {
@@ -158,7 +259,7 @@ func (ir *IndexRepository) CheckContent(id [32]byte, hash [32]byte) (bool, *mode
 	ir.mu.Lock()
 	defer ir.mu.Unlock()
 
-	err = ir.DB.Update(func(txn *badger.Txn) error {
+	err = ir.update(func(txn *badger.Txn) error {
 		pref := fmt.Appendf(nil, "%s_", hash)
 
 		opts := badger.DefaultIteratorOptions
@@ -168,13 +269,17 @@ func (ir *IndexRepository) CheckContent(id [32]byte, hash [32]byte) (bool, *mode
 
 		if it.Seek(pref); it.ValidForPrefix(pref){
 			item := it.Item()
-			k := item.Key()
+			k := item.KeyCopy(nil)
 			doc, err = ir.GetDocumentByID([32]byte(k[33:]))
-			if err != nil {
+			if err == nil {
+				return errors.New(existError)
+			} else if !errors.Is(err, model.ErrNotFound) {
+				return err
+			}
+			// the document with this content was deleted, the page is indexed again
+			if err := txn.Delete(k); err != nil {
 				return err
 			}
-
-			return errors.New(existError)
 		}
 
 		if err := txn.Set(key, []byte{}); err != nil {
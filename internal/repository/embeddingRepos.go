@@ -0,0 +1,286 @@
+package repository
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/dgraph-io/badger/v3"
+)
+
+const (
+	EmbeddingKeyPrefix = "vec:"
+
+	Float32Encoding = "float32"
+	Int8Encoding = "int8"
+)
+
+const (
+	float32Tag byte = 1
+	int8Tag byte = 2
+)
+
+var errCorruptedEmbeddings = errors.New("corrupted embeddings record")
+
+// SetEmbeddingEncoding selects how new embeddings are written, records are self describing
+// so indexes holding both encodings stay readable.
+func (ir *IndexRepository) SetEmbeddingEncoding(encoding string) error {
+	switch encoding {
+	case "":
+		encoding = Float32Encoding
+	case Float32Encoding, Int8Encoding:
+	default:
+		return fmt.Errorf("unknown embedding encoding: %q", encoding)
+	}
+	ir.mu.Lock()
+	defer ir.mu.Unlock()
+	ir.encoding = encoding
+	return nil
+}
+
+// GetEmbeddings returns the chunk embeddings of a document, falling back to the vectors
+// inlined into documents written before embeddings got their own records.
+func (ir *IndexRepository) GetEmbeddings(docID [32]byte) ([][]float64, error) {
+	var vecs [][]float64
+	return vecs, ir.DB.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(EmbeddingKeyPrefix + string(docID[:])))
+		if err == nil {
+			return item.Value(func(val []byte) error {
+				vecs, err = decodeEmbeddings(val)
+				return err
+			})
+		} else if err != badger.ErrKeyNotFound {
+			return err
+		}
+
+		item, err = txn.Get([]byte(DocumentKeyPrefix + string(docID[:])))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			vecs, err = legacyEmbeddings(val)
+			return err
+		})
+	})
+}
+
+// MigrateEmbeddings moves vectors inlined into document records to embedding records and,
+// with reencode set, rewrites existing embedding records in the current encoding.
+func (ir *IndexRepository) MigrateEmbeddings(reencode bool) (moved int, reencoded int, err error) {
+	ir.mu.Lock()
+	defer ir.mu.Unlock()
+
+	wb := ir.DB.NewWriteBatch()
+	defer wb.Cancel()
+
+	err = ir.DB.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		prefix := []byte(DocumentKeyPrefix)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			item := it.Item()
+			val, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			vecs, err := legacyEmbeddings(val)
+			if err != nil {
+				return err
+			}
+			if vecs == nil {
+				continue
+			}
+			doc, err := ir.bytesToDocument(val)
+			if err != nil {
+				return err
+			}
+			docBytes, err := ir.documentToBytes(doc)
+			if err != nil {
+				return err
+			}
+			encoded, err := encodeEmbeddings(vecs, ir.encoding)
+			if err != nil {
+				return err
+			}
+			if err := wb.Set([]byte(EmbeddingKeyPrefix + string(doc.Id[:])), encoded); err != nil {
+				return err
+			}
+			if err := wb.Set(item.KeyCopy(nil), docBytes); err != nil {
+				return err
+			}
+			moved++
+		}
+
+		if !reencode {
+			return nil
+		}
+		prefix = []byte(EmbeddingKeyPrefix)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			item := it.Item()
+			val, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			if len(val) > 0 && val[0] == encodingTag(ir.encoding) {
+				continue
+			}
+			vecs, err := decodeEmbeddings(val)
+			if err != nil {
+				return err
+			}
+			encoded, err := encodeEmbeddings(vecs, ir.encoding)
+			if err != nil {
+				return err
+			}
+			if err := wb.Set(item.KeyCopy(nil), encoded); err != nil {
+				return err
+			}
+			reencoded++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := wb.Flush(); err != nil {
+		return 0, 0, err
+	}
+	ir.generation.Add(1)
+	return moved, reencoded, nil
+}
+
+func legacyEmbeddings(docBytes []byte) ([][]float64, error) {
+	var payload struct {
+		Vec [][]float64 `json:"word_vec"`
+	}
+	if err := json.Unmarshal(docBytes, &payload); err != nil {
+		return nil, err
+	}
+	return payload.Vec, nil
+}
+
+func encodingTag(encoding string) byte {
+	if encoding == Int8Encoding {
+		return int8Tag
+	}
+	return float32Tag
+}
+
+// encodeEmbeddings layout: encoding tag, chunk count, dimension, then every chunk as
+// float32 components, or for int8 as a float32 scale followed by the quantized components.
+func encodeEmbeddings(vecs [][]float64, encoding string) ([]byte, error) {
+	dim := 0
+	if len(vecs) > 0 {
+		dim = len(vecs[0])
+	}
+	tag := encodingTag(encoding)
+	size := 4 * dim
+	if tag == int8Tag {
+		size = 4 + dim
+	}
+
+	buf := make([]byte, 0, 7 + len(vecs) * size)
+	buf = append(buf, tag)
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(vecs)))
+	buf = binary.BigEndian.AppendUint32(buf, uint32(dim))
+	for _, vec := range vecs {
+		if len(vec) != dim {
+			return nil, fmt.Errorf("embedding chunks have different dimensions: %d and %d", dim, len(vec))
+		}
+		if tag == float32Tag {
+			for _, v := range vec {
+				buf = binary.BigEndian.AppendUint32(buf, math.Float32bits(float32(v)))
+			}
+			continue
+		}
+
+		// symmetric quantization, the largest component maps to 127
+		maxAbs := 0.0
+		for _, v := range vec {
+			maxAbs = max(maxAbs, math.Abs(v))
+		}
+		scale := maxAbs / 127
+		buf = binary.BigEndian.AppendUint32(buf, math.Float32bits(float32(scale)))
+		for _, v := range vec {
+			q := 0.0
+			if scale > 0 {
+				q = math.Round(v / scale)
+			}
+			buf = append(buf, byte(int8(q)))
+		}
+	}
+	return buf, nil
+}
+
+func decodeEmbeddings(data []byte) ([][]float64, error) {
+	if len(data) < 7 {
+		return nil, errCorruptedEmbeddings
+	}
+	tag := data[0]
+	count := int(binary.BigEndian.Uint16(data[1:]))
+	dim := int(binary.BigEndian.Uint32(data[3:]))
+	data = data[7:]
+
+	size := 4 * dim
+	if tag == int8Tag {
+		size = 4 + dim
+	} else if tag != float32Tag {
+		return nil, errCorruptedEmbeddings
+	}
+	if len(data) != count * size {
+		return nil, errCorruptedEmbeddings
+	}
+
+	vecs := make([][]float64, count)
+	for i := range vecs {
+		chunk := data[i * size:(i + 1) * size]
+		vec := make([]float64, dim)
+		if tag == float32Tag {
+			for j := range vec {
+				vec[j] = float64(math.Float32frombits(binary.BigEndian.Uint32(chunk[4 * j:])))
+			}
+		} else {
+			scale := float64(math.Float32frombits(binary.BigEndian.Uint32(chunk)))
+			for j := range vec {
+				vec[j] = float64(int8(chunk[4 + j])) * scale
+			}
+		}
+		vecs[i] = vec
+	}
+	return vecs, nil
+}
+
+const EmbeddingCacheKeyPrefix = "embcache:"
+
+// GetCachedEmbedding returns nil when nothing was cached under key.
+func (ir *IndexRepository) GetCachedEmbedding(key [32]byte) ([][]float64, error) {
+	var vecs [][]float64
+	return vecs, ir.DB.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(EmbeddingCacheKeyPrefix + string(key[:])))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			vecs, err = decodeEmbeddings(val)
+			return err
+		})
+	})
+}
+
+// CacheEmbedding always stores float32 records, cached query vectors are compared exactly.
+func (ir *IndexRepository) CacheEmbedding(key [32]byte, vecs [][]float64) error {
+	encoded, err := encodeEmbeddings(vecs, Float32Encoding)
+	if err != nil {
+		return err
+	}
+	return ir.DB.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(EmbeddingCacheKeyPrefix + string(key[:])), encoded)
+	})
+}
@@ -6,19 +6,22 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"fmt"
 
-	"slices"
-
 	"github.com/box1bs/monocle/internal/model"
 	"github.com/dgraph-io/badger/v3"
 )
 
 type IndexRepository struct {
-	DB *badger.DB
-	wg *sync.WaitGroup
-	mu *sync.Mutex
+	DB 			*badger.DB
+	mu 			*sync.Mutex
+	encoding 	string
+	queryLogTTL time.Duration
+	seq 		uint32
+	generation 	atomic.Uint64
 }
 
 func NewIndexRepository(path string) (*IndexRepository, error) {
@@ -26,11 +29,31 @@ func NewIndexRepository(path string) (*IndexRepository, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &IndexRepository{
-		DB: db,
-		wg: new(sync.WaitGroup),
-		mu: new(sync.Mutex),
-	}, nil
+	ir := &IndexRepository{
+		DB: 		db,
+		mu: 		new(sync.Mutex),
+		encoding: 	Float32Encoding,
+	}
+	if err := ir.ensureCorpusStats(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("rebuilding corpus statistics: %w", err)
+	}
+	return ir, nil
+}
+
+// Generation counts the commits that change what a search finds: documents, postings, vectors
+// and clicks. Logged queries and cached embeddings leave it alone.
+func (ir *IndexRepository) Generation() uint64 {
+	return ir.generation.Load()
+}
+
+// update is DB.Update for changes to the searchable index, committed changes move the generation on.
+func (ir *IndexRepository) update(fn func(txn *badger.Txn) error) error {
+	if err := ir.DB.Update(fn); err != nil {
+		return err
+	}
+	ir.generation.Add(1)
+	return nil
 }
 
 func (ir *IndexRepository) LoadVisitedUrls(visitedURLs *sync.Map) error {
@@ -97,137 +120,165 @@ func (ir *IndexRepository) LoadPageRank() (map[string]int, error) {
 	return out, nil
 }
 
-func (ir *IndexRepository) IndexDocumentWords(c context.Context, docID [32]byte, sequence []int, positions map[string][]model.Position) error {
-	wordFreq := make(map[int]int)
-	for _, word := range sequence {
-		wordFreq[word]++
-	}
-	encoded, err := json.Marshal(positions)
-	if err != nil {
-		return err
-	}
+// EnsureEmbeddingDimension records the vector size of a new index and rejects providers
+// producing a different size for an existing one.
+func (ir *IndexRepository) EnsureEmbeddingDimension(dim int) error {
 	ir.mu.Lock()
 	defer ir.mu.Unlock()
 	return ir.DB.Update(func(txn *badger.Txn) error {
-		for word, freq := range wordFreq {
-			select {
-			case <- c.Done():
-				return nil
-			default:
-			}
-			key := fmt.Appendf(nil, WordDocumentKeyFormat, word, docID, freq)
-			if err := txn.Set(key, encoded); err != nil {
-				return err
-			}
+		key := []byte("meta:embedding_dim")
+		item, err := txn.Get(key)
+		if err == badger.ErrKeyNotFound {
+			return txn.Set(key, []byte(strconv.Itoa(dim)))
+		} else if err != nil {
+			return err
+		}
+		val, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		stored, err := strconv.Atoi(string(val))
+		if err != nil {
+			return err
+		}
+		if stored != dim {
+			return fmt.Errorf("index was built with %d dimensional embeddings, provider produces %d", stored, dim)
 		}
 		return nil
 	})
 }
 
-func (ir *IndexRepository) GetDocumentsByWord(word int) (map[[32]byte]*model.WordCountAndPositions, error) {
-	revertWordIndex := make(map[[32]byte]*model.WordCountAndPositions)
-	wprefix := fmt.Appendf(nil, "%d_", word)
-	return revertWordIndex, ir.DB.View(func(txn *badger.Txn) error {
-		it1 := txn.NewIterator(badger.DefaultIteratorOptions)
-		defer it1.Close()
-		errCh := make(chan error)
-		ir.wg.Add(1)
-		go func() {
-			defer ir.wg.Done()
-			for it1.Seek(wprefix); it1.ValidForPrefix(wprefix); it1.Next() {
-				item := it1.Item()
-				key := string(item.Key())
-				keyPart := strings.TrimPrefix(key, string(wprefix))
-				splited := strings.SplitN(keyPart, "_", 2)
-				id := [32]byte([]byte(splited[0]))
-				val, err := item.ValueCopy(nil)
-				if err != nil {
-					errCh <- err
-					return
-				}
-				positions := []model.Position{}
-				if err := json.Unmarshal(val, &positions); err != nil {
-					errCh <- err
-					return
-				}
-				freq, _ := strconv.Atoi(string(splited[1]))
-				revertWordIndex[id] = &model.WordCountAndPositions{Count: freq, Positions: positions}
+// EnsureEmbeddingVersion records what embedded the vectors of a new index and rejects an index
+// embedded by something else, or from before versions were recorded, its vectors can't be compared
+// with new ones and it has to be built again from scratch.
+func (ir *IndexRepository) EnsureEmbeddingVersion(version string) error {
+	ir.mu.Lock()
+	defer ir.mu.Unlock()
+	return ir.DB.Update(func(txn *badger.Txn) error {
+		key := []byte("meta:embedding_version")
+		item, err := txn.Get(key)
+		if err == badger.ErrKeyNotFound {
+			opts := badger.DefaultIteratorOptions
+			opts.PrefetchValues = false
+			it := txn.NewIterator(opts)
+			defer it.Close()
+			prefix := []byte(DocumentKeyPrefix)
+			if it.Seek(prefix); !it.ValidForPrefix(prefix) {
+				return txn.Set(key, []byte(version))
 			}
-		}()
-
-		go func() {
-			ir.wg.Wait()
-			close(errCh)
-		}()
-		
-		return <-errCh
+			return fmt.Errorf("index was embedded before embedding versions were recorded, this build embeds with %s: remove %s and crawl again", version, ir.DB.Opts().Dir)
+		} else if err != nil {
+			return err
+		}
+		stored, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		if string(stored) != version {
+			return fmt.Errorf("index was embedded with %s, this build embeds with %s: remove %s and crawl again", stored, version, ir.DB.Opts().Dir)
+		}
+		return nil
 	})
 }
 
-func (ir *IndexRepository) IndexNGrams(word string, nGrams ...string) error {
+// EnsureStemmerVersion records the stemmer version of a new index and rejects an index whose
+// words were stemmed by another version, or by one from before versions were recorded. Postings
+// are keyed by stems, such an index has to be built again from scratch.
+func (ir *IndexRepository) EnsureStemmerVersion(version int) error {
 	ir.mu.Lock()
 	defer ir.mu.Unlock()
 	return ir.DB.Update(func(txn *badger.Txn) error {
-		for _, nGram := range nGrams {
-			key := fmt.Appendf(nil, "ngram:%s", string(nGram))
-			item, err := txn.Get(key)
-			if err == badger.ErrKeyNotFound {
-				if err = txn.Set(key, []byte(word)); err != nil {
-					return err
-				}
-				continue
-			} else if err != nil {
-				return err
-			}
+		key := []byte("meta:stemmer_version")
+		stored := 0
+		item, err := txn.Get(key)
+		if err == nil {
 			val, err := item.ValueCopy(nil)
 			if err != nil {
 				return err
 			}
-			words := strings.Split(string(val), ",")
-			if slices.Contains(words, nGram) {
-				continue
-			}
-			words = append(words, word)
-			if err = txn.Set(key, []byte(strings.Join(words, ","))); err != nil {
+			if stored, err = strconv.Atoi(string(val)); err != nil {
 				return err
 			}
+		} else if err != badger.ErrKeyNotFound {
+			return err
 		}
-		return nil
+		if stored == version {
+			return nil
+		}
+
+		if stored == 0 {
+			opts := badger.DefaultIteratorOptions
+			opts.PrefetchValues = false
+			it := txn.NewIterator(opts)
+			defer it.Close()
+			prefix := []byte(DocumentKeyPrefix)
+			if it.Seek(prefix); !it.ValidForPrefix(prefix) {
+				return txn.Set(key, []byte(strconv.Itoa(version)))
+			}
+		}
+		if stored == 0 {
+			return fmt.Errorf("index words were stemmed before stemmer versions were recorded, this build stems with version %d: remove %s and crawl again", version, ir.DB.Opts().Dir)
+		}
+		return fmt.Errorf("index words were stemmed by stemmer version %d, this build stems with version %d: remove %s and crawl again", stored, version, ir.DB.Opts().Dir)
 	})
 }
 
-func (ir *IndexRepository) GetWordsByNGrams(nGrams ...string) ([]string, error) {
-	wordSet := make(map[string]struct{})
-	err := ir.DB.View(func(txn *badger.Txn) error {
-		for _, nGram := range nGrams {
-			key := fmt.Sprintf("ngram:%s", nGram)
-			item, err := txn.Get([]byte(key))
-			if err == badger.ErrKeyNotFound {
+// putPostings writes the postings of a document's words and counts them in the statistics.
+func putPostings(c context.Context, txn *badger.Txn, docID [32]byte, sequence []int, positions map[int][]model.Position) error {
+	wordFreq := make(map[int]int)
+	for _, word := range sequence {
+		wordFreq[word]++
+	}
+	// postings of an earlier indexing of the document go, their frequencies may have changed
+	if err := removeDocTerms(txn, docID); err != nil {
+		return err
+	}
+	record := &docTerms{Terms: wordFreq, Fields: map[byte]int{}}
+	for word, freq := range wordFreq {
+		select {
+		case <- c.Done():
+			return c.Err()
+		default:
+		}
+		for _, p := range positions[word] {
+			record.Fields[p.Type]++
+		}
+		encoded, err := json.Marshal(positions[word])
+		if err != nil {
+			return err
+		}
+		key := fmt.Appendf(nil, WordDocumentKeyFormat, word, docID, freq)
+		if err := txn.Set(key, encoded); err != nil {
+			return err
+		}
+	}
+	return putDocTerms(txn, docID, record)
+}
+
+func (ir *IndexRepository) GetDocumentsByWord(word int) (map[[32]byte]*model.WordCountAndPositions, error) {
+	revertWordIndex := make(map[[32]byte]*model.WordCountAndPositions)
+	wprefix := fmt.Appendf(nil, "%d_", word)
+	return revertWordIndex, ir.DB.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		for it.Seek(wprefix); it.ValidForPrefix(wprefix); it.Next() {
+			item := it.Item()
+			keyPart := item.Key()[len(wprefix):]
+			if len(keyPart) < 34 {
 				continue
-			} else if err != nil {
-				return err
 			}
+			id := [32]byte(keyPart[:32])
 			val, err := item.ValueCopy(nil)
 			if err != nil {
 				return err
 			}
-			words := strings.SplitSeq(string(val), ",")
-			for word := range words {
-				if _, exists := wordSet[word]; exists {
-					continue
-				}
-				wordSet[word] = struct{}{}
+			positions := []model.Position{}
+			if err := json.Unmarshal(val, &positions); err != nil {
+				return err
 			}
+			freq, _ := strconv.Atoi(string(keyPart[33:]))
+			revertWordIndex[id] = &model.WordCountAndPositions{Count: freq, Positions: positions}
 		}
 		return nil
 	})
-	if err != nil {
-		return nil, err
-	}
-
-	words := make([]string, 0, len(wordSet))
-	for word := range wordSet {
-		words = append(words, word)
-	}
-	return words, nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/dgraph-io/badger/v3"
+)
+
+const (
+	// NGramKeyPrefix keys the n-gram index as a set: ngram:<n-gram>:<word> exists, with an empty value,
+	// for every word the n-gram occurs in.
+	NGramKeyPrefix = "ngram:"
+	// NGramCountKeyPrefix keys the number of words stored under an n-gram.
+	NGramCountKeyPrefix = "ngramcount:"
+	// MaxNGramWords caps the words stored under one n-gram, an n-gram that common tells
+	// too little about a word to be worth the space.
+	MaxNGramWords = 5000
+)
+
+func nGramKey(nGram, word string) []byte {
+	return []byte(NGramKeyPrefix + nGram + ":" + word)
+}
+
+// IndexNGrams adds the words of a document under their n-grams in one transaction,
+// words already stored under an n-gram and n-grams at MaxNGramWords are skipped.
+func (ir *IndexRepository) IndexNGrams(words map[string][]string) error {
+	ir.mu.Lock()
+	defer ir.mu.Unlock()
+	return ir.DB.Update(func(txn *badger.Txn) error {
+		counts := map[string]int{}
+		changed := map[string]struct{}{}
+		for word, nGrams := range words {
+			for _, nGram := range nGrams {
+				count, ok := counts[nGram]
+				if !ok {
+					var err error
+					if count, err = getCount(txn, []byte(NGramCountKeyPrefix + nGram)); err != nil {
+						return err
+					}
+					counts[nGram] = count
+				}
+				if count >= MaxNGramWords {
+					continue
+				}
+				key := nGramKey(nGram, word)
+				if _, err := txn.Get(key); err == nil {
+					continue
+				} else if err != badger.ErrKeyNotFound {
+					return err
+				}
+				if err := txn.Set(key, nil); err != nil {
+					return err
+				}
+				counts[nGram]++
+				changed[nGram] = struct{}{}
+			}
+		}
+		for nGram := range changed {
+			if err := txn.Set([]byte(NGramCountKeyPrefix + nGram), []byte(strconv.Itoa(counts[nGram]))); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetWordsByNGrams returns the words stored under any of nGrams.
+func (ir *IndexRepository) GetWordsByNGrams(nGrams ...string) ([]string, error) {
+	wordSet := make(map[string]struct{})
+	err := ir.DB.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for _, nGram := range nGrams {
+			prefix := []byte(NGramKeyPrefix + nGram + ":")
+			for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+				wordSet[string(it.Item().Key()[len(prefix):])] = struct{}{}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	words := make([]string, 0, len(wordSet))
+	for word := range wordSet {
+		words = append(words, word)
+	}
+	return words, nil
+}
+
+// MigrateNGrams rewrites n-grams stored the old way, as a comma joined word list under ngram:<n-gram>,
+// into the set keys and returns the number of n-grams rewritten.
+func (ir *IndexRepository) MigrateNGrams() (int, error) {
+	legacy := map[string][]string{}
+	err := ir.DB.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		prefix := []byte(NGramKeyPrefix)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			nGram := string(it.Item().Key()[len(prefix):])
+			if strings.Contains(nGram, ":") {
+				continue
+			}
+			val, err := it.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			legacy[nGram] = strings.Split(string(val), ",")
+		}
+		return nil
+	})
+	if err != nil || len(legacy) == 0 {
+		return 0, err
+	}
+
+	words := map[string][]string{}
+	for nGram, list := range legacy {
+		for _, word := range list {
+			if word != "" {
+				words[word] = append(words[word], nGram)
+			}
+		}
+	}
+	// words go in batches to keep every transaction well below badger's size limit
+	batch := map[string][]string{}
+	for word, nGrams := range words {
+		batch[word] = nGrams
+		if len(batch) < migrateNGramBatch {
+			continue
+		}
+		if err := ir.IndexNGrams(batch); err != nil {
+			return 0, err
+		}
+		clear(batch)
+	}
+	if err := ir.IndexNGrams(batch); err != nil {
+		return 0, err
+	}
+
+	ir.mu.Lock()
+	defer ir.mu.Unlock()
+	wb := ir.DB.NewWriteBatch()
+	defer wb.Cancel()
+	for nGram := range legacy {
+		if err := wb.Delete([]byte(NGramKeyPrefix + nGram)); err != nil {
+			return 0, err
+		}
+	}
+	return len(legacy), wb.Flush()
+}
+
+const migrateNGramBatch = 1000
+
+func getCount(txn *badger.Txn, key []byte) (int, error) {
+	item, err := txn.Get(key)
+	if err == badger.ErrKeyNotFound {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	val, err := item.ValueCopy(nil)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(string(val))
+}
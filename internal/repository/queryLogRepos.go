@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	"github.com/box1bs/monocle/internal/model"
+	"github.com/dgraph-io/badger/v3"
+)
+
+// QueryLogKeyPrefix keys the query log: qlog:<time key> holds a json encoded entry.
+const QueryLogKeyPrefix = "qlog:"
+
+// SetQueryLogRetention makes logged queries expire after retention, zero keeps them forever.
+func (ir *IndexRepository) SetQueryLogRetention(retention time.Duration) {
+	ir.mu.Lock()
+	defer ir.mu.Unlock()
+	ir.queryLogTTL = retention
+}
+
+// timeKey is prefix followed by t in big endian unix nanoseconds and a sequence number telling
+// apart records of the same nanosecond, so keys sort by time. ir.mu must be held.
+func (ir *IndexRepository) timeKey(prefix string, t time.Time) []byte {
+	ir.seq++
+	key := binary.BigEndian.AppendUint64([]byte(prefix), uint64(max(t.UnixNano(), 0)))
+	return binary.BigEndian.AppendUint32(key, ir.seq)
+}
+
+// timePrefix is where the keys of records made at or after since start, the zero time starts at prefix.
+func timePrefix(prefix string, since time.Time) []byte {
+	if since.IsZero() {
+		return []byte(prefix)
+	}
+	return binary.BigEndian.AppendUint64([]byte(prefix), uint64(max(since.UnixNano(), 0)))
+}
+
+func (ir *IndexRepository) LogQuery(entry *model.QueryLogEntry) error {
+	val, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	ir.mu.Lock()
+	defer ir.mu.Unlock()
+	e := badger.NewEntry(ir.timeKey(QueryLogKeyPrefix, entry.Time), val)
+	if ir.queryLogTTL > 0 {
+		e = e.WithTTL(ir.queryLogTTL)
+	}
+	return ir.DB.Update(func(txn *badger.Txn) error {
+		return txn.SetEntry(e)
+	})
+}
+
+// QueryLog returns the logged queries searched at or after since, oldest first.
+func (ir *IndexRepository) QueryLog(since time.Time) ([]model.QueryLogEntry, error) {
+	entries := []model.QueryLogEntry{}
+	err := ir.DB.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		prefix := []byte(QueryLogKeyPrefix)
+		for it.Seek(timePrefix(QueryLogKeyPrefix, since)); it.ValidForPrefix(prefix); it.Next() {
+			var entry model.QueryLogEntry
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &entry)
+			}); err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	return entries, err
+}
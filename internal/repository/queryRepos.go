@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"strconv"
+
+	"github.com/dgraph-io/badger/v3"
+)
+
+// QueryCountPrefix keys past queries: query:<normalized query> holds the number of times it was searched.
+const QueryCountPrefix = "query:"
+
+func (ir *IndexRepository) CountQuery(query string) error {
+	ir.mu.Lock()
+	defer ir.mu.Unlock()
+	return ir.DB.Update(func(txn *badger.Txn) error {
+		key := []byte(QueryCountPrefix + query)
+		count, err := getCount(txn, key)
+		if err != nil {
+			return err
+		}
+		return txn.Set(key, []byte(strconv.Itoa(count + 1)))
+	})
+}
+
+// LoadQueryCounts calls add for every past query with the number of times it was searched.
+func (ir *IndexRepository) LoadQueryCounts(add func(query string, count int)) error {
+	return ir.DB.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		prefix := []byte(QueryCountPrefix)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			val, err := it.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			count, err := strconv.Atoi(string(val))
+			if err != nil {
+				return err
+			}
+			add(string(it.Item().Key()[len(prefix):]), count)
+		}
+		return nil
+	})
+}
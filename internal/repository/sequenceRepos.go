@@ -41,12 +41,12 @@ func (ir *IndexRepository) SaveToSequence(words ...string) ([]int, error) {
     ir.mu.Lock()
 	defer ir.mu.Unlock()
 
-    err := ir.DB.Update(func(txn *badger.Txn) error {
+    err := ir.update(func(txn *badger.Txn) error {
         for _, word := range words {
             if len(word) == 0 {
                 continue
             }
-            key := []byte(word)
+            key := []byte("word:" + word)
             item, err := txn.Get(key)
             if err == nil {
                 val, err := item.ValueCopy(nil)
@@ -59,7 +59,7 @@ func (ir *IndexRepository) SaveToSequence(words ...string) ([]int, error) {
                 }
                 sequence = append(sequence, id)
             } else if err == badger.ErrKeyNotFound {
-                maxId, err := ir.getLastId()
+                maxId, err := ir.getLastId(txn)
                 if err != nil {
                     return err
                 }
@@ -85,28 +85,16 @@ func (ir *IndexRepository) SaveToSequence(words ...string) ([]int, error) {
     return sequence, nil
 }
 
-func (ir *IndexRepository) getLastId() (int, error) {
-	var maxId int
-    err := ir.DB.View(func(txn *badger.Txn) error {
-        item, err := txn.Get([]byte("max_id"))
-        if err == badger.ErrKeyNotFound {
-            maxId = 1
-            return nil
-        } else if err != nil {
-            return err
-        }
-        maxIdBytes, err := item.ValueCopy(nil)
-        if err != nil {
-            return err
-        }
-        maxId, err = strconv.Atoi(string(maxIdBytes))
-        if err != nil {
-            return err
-        }
-        return nil
-    })
+func (ir *IndexRepository) getLastId(txn *badger.Txn) (int, error) {
+    item, err := txn.Get([]byte("max_id"))
+    if err == badger.ErrKeyNotFound {
+        return 1, nil
+    } else if err != nil {
+        return 0, err
+    }
+    maxIdBytes, err := item.ValueCopy(nil)
     if err != nil {
         return 0, err
     }
-    return maxId, nil
-}
\ No newline at end of file
+    return strconv.Atoi(string(maxIdBytes))
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/dgraph-io/badger/v3"
+)
+
+// SpellingKeyPrefix keys the spelling dictionary: dict:<word> holds the number of documents the word occurs in.
+// SpellingWordsKeyPrefix keys the words each document counted in, dictwords:<doc id> holds them as a JSON list.
+const (
+	SpellingKeyPrefix = "dict:"
+	SpellingWordsKeyPrefix = "dictwords:"
+)
+
+// SetSpellingWords replaces the dictionary words counted for a document with words, which should be distinct.
+// Words the document didn't count before are added and words it no longer has are taken out, so indexing
+// the same document again leaves the counts alone. Without words the document is taken out entirely.
+func (ir *IndexRepository) SetSpellingWords(docID [32]byte, words ...string) (added, removed []string, err error) {
+	ir.mu.Lock()
+	defer ir.mu.Unlock()
+	err = ir.DB.Update(func(txn *badger.Txn) error {
+		added, removed = nil, nil
+		key := []byte(SpellingWordsKeyPrefix + string(docID[:]))
+		old := map[string]struct{}{}
+		item, err := txn.Get(key)
+		if err == nil {
+			var stored []string
+			if err := item.Value(func(val []byte) error {
+				return json.Unmarshal(val, &stored)
+			}); err != nil {
+				return err
+			}
+			for _, word := range stored {
+				old[word] = struct{}{}
+			}
+		} else if err != badger.ErrKeyNotFound {
+			return err
+		}
+
+		for _, word := range words {
+			if _, ok := old[word]; ok {
+				delete(old, word)
+				continue
+			}
+			if err := addCount(txn, []byte(SpellingKeyPrefix + word), 1); err != nil {
+				return err
+			}
+			added = append(added, word)
+		}
+		for word := range old {
+			if err := addCount(txn, []byte(SpellingKeyPrefix + word), -1); err != nil {
+				return err
+			}
+			removed = append(removed, word)
+		}
+
+		if len(words) == 0 {
+			return txn.Delete(key)
+		}
+		encoded, err := json.Marshal(words)
+		if err != nil {
+			return err
+		}
+		return txn.Set(key, encoded)
+	})
+	return added, removed, err
+}
+
+// LoadSpellingDictionary calls add for every dictionary word. Indexes built before the dictionary
+// existed get the words of the n-gram index, each counted as one document, run MigrateNGrams first
+// on indexes older than the n-gram set keys.
+func (ir *IndexRepository) LoadSpellingDictionary(add func(word string, df int)) error {
+	found := false
+	err := ir.DB.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		prefix := []byte(SpellingKeyPrefix)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			val, err := it.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			df, err := strconv.Atoi(string(val))
+			if err != nil {
+				return err
+			}
+			add(string(it.Item().Key()[len(prefix):]), df)
+			found = true
+		}
+		return nil
+	})
+	if err != nil || found {
+		return err
+	}
+
+	seen := map[string]struct{}{}
+	return ir.DB.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		prefix := []byte(NGramKeyPrefix)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			_, word, ok := strings.Cut(string(it.Item().Key()[len(prefix):]), ":")
+			if _, dup := seen[word]; ok && !dup && word != "" {
+				seen[word] = struct{}{}
+				add(word, 1)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,355 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/box1bs/monocle/internal/model"
+	"github.com/dgraph-io/badger/v3"
+)
+
+const (
+	// StatsKeyPrefix keys the corpus statistics, kept in the transactions that change documents and postings:
+	// stats:docs and stats:tokens count documents and their words, stats:field:<type> the positions
+	// of a passage type, stats:df:<term id> the documents a term occurs in and stats:terms:<doc id>
+	// what a document added, so it can be taken out again.
+	StatsKeyPrefix = "stats:"
+	statsDocsKey = StatsKeyPrefix + "docs"
+	statsTokensKey = StatsKeyPrefix + "tokens"
+	statsFieldPrefix = StatsKeyPrefix + "field:"
+	statsDFPrefix = StatsKeyPrefix + "df:"
+	statsTermsPrefix = StatsKeyPrefix + "terms:"
+)
+
+// docTerms is what indexing a document's words added to the statistics,
+// term ids with their frequency in the document and positions per passage type.
+type docTerms struct {
+	Terms 	map[int]int 	`json:"terms"`
+	Fields 	map[byte]int 	`json:"fields"`
+}
+
+func dfKey(term int) []byte {
+	return []byte(statsDFPrefix + strconv.Itoa(term))
+}
+
+func fieldKey(field byte) []byte {
+	return []byte(statsFieldPrefix + string(field))
+}
+
+// addCount adds delta to the counter under key, counters falling to zero are deleted.
+func addCount(txn *badger.Txn, key []byte, delta int) error {
+	if delta == 0 {
+		return nil
+	}
+	count, err := getCount(txn, key)
+	if err != nil {
+		return err
+	}
+	if count += delta; count <= 0 {
+		if err := txn.Delete(key); err != nil && err != badger.ErrKeyNotFound {
+			return err
+		}
+		return nil
+	}
+	return txn.Set(key, []byte(strconv.Itoa(count)))
+}
+
+// addTotal is addCount for stats:docs and stats:tokens, which stay stored at zero so an emptied
+// index with words left isn't taken for one built before the statistics were kept.
+func addTotal(txn *badger.Txn, key []byte, delta int) error {
+	count, err := getCount(txn, key)
+	if err != nil {
+		return err
+	}
+	return txn.Set(key, []byte(strconv.Itoa(max(count + delta, 0))))
+}
+
+// addDocTerms counts the terms and fields of a document in, with sign -1 it takes them out again.
+func addDocTerms(txn *badger.Txn, record *docTerms, sign int) error {
+	for term := range record.Terms {
+		if err := addCount(txn, dfKey(term), sign); err != nil {
+			return err
+		}
+	}
+	for field, n := range record.Fields {
+		if err := addCount(txn, fieldKey(field), sign * n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeDocTerms deletes the postings of a document and takes them out of the statistics.
+func removeDocTerms(txn *badger.Txn, docID [32]byte) error {
+	key := []byte(statsTermsPrefix + string(docID[:]))
+	item, err := txn.Get(key)
+	if err == badger.ErrKeyNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	record := &docTerms{}
+	if err := item.Value(func(val []byte) error {
+		return json.Unmarshal(val, record)
+	}); err != nil {
+		return err
+	}
+	for term, freq := range record.Terms {
+		if err := txn.Delete(fmt.Appendf(nil, WordDocumentKeyFormat, term, docID, freq)); err != nil {
+			return err
+		}
+	}
+	if err := addDocTerms(txn, record, -1); err != nil {
+		return err
+	}
+	return txn.Delete(key)
+}
+
+func putDocTerms(txn *badger.Txn, docID [32]byte, record *docTerms) error {
+	encoded, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err := addDocTerms(txn, record, 1); err != nil {
+		return err
+	}
+	return txn.Set([]byte(statsTermsPrefix + string(docID[:])), encoded)
+}
+
+// countDocument counts doc in, replacing the length of a stored document with the same id.
+func (ir *IndexRepository) countDocument(txn *badger.Txn, doc *model.Document) error {
+	old, err := ir.storedDocument(txn, doc.Id)
+	if err != nil {
+		return err
+	}
+	delta := doc.WordCount
+	if old == nil {
+		if err := addTotal(txn, []byte(statsDocsKey), 1); err != nil {
+			return err
+		}
+	} else {
+		delta -= old.WordCount
+	}
+	return addTotal(txn, []byte(statsTokensKey), delta)
+}
+
+func (ir *IndexRepository) storedDocument(txn *badger.Txn, docID [32]byte) (*model.Document, error) {
+	item, err := txn.Get([]byte(DocumentKeyPrefix + string(docID[:])))
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	val, err := item.ValueCopy(nil)
+	if err != nil {
+		return nil, err
+	}
+	return ir.bytesToDocument(val)
+}
+
+// GetCorpusStats reads the corpus statistics in one transaction, with the document frequencies of terms.
+func (ir *IndexRepository) GetCorpusStats(terms ...int) (*model.CorpusStats, error) {
+	stats := &model.CorpusStats{Fields: map[byte]int{}, DF: make(map[int]int, len(terms))}
+	return stats, ir.DB.View(func(txn *badger.Txn) error {
+		var err error
+		if stats.Docs, err = getCount(txn, []byte(statsDocsKey)); err != nil {
+			return err
+		}
+		if stats.Tokens, err = getCount(txn, []byte(statsTokensKey)); err != nil {
+			return err
+		}
+		for _, term := range terms {
+			if stats.DF[term], err = getCount(txn, dfKey(term)); err != nil {
+				return err
+			}
+		}
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		prefix := []byte(statsFieldPrefix)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			key := it.Item().Key()
+			if len(key) != len(prefix) + 1 {
+				continue
+			}
+			val, err := it.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			if stats.Fields[key[len(prefix)]], err = strconv.Atoi(string(val)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteDocument removes a document with its embeddings, chunks and postings and takes it out
+// of the corpus statistics. Its nodes in the vector graph are the indexer's to remove.
+func (ir *IndexRepository) DeleteDocument(docID [32]byte) error {
+	ir.mu.Lock()
+	defer ir.mu.Unlock()
+	return ir.update(func(txn *badger.Txn) error {
+		doc, err := ir.storedDocument(txn, docID)
+		if err != nil {
+			return err
+		}
+		if doc == nil {
+			return badger.ErrKeyNotFound
+		}
+		if err := addTotal(txn, []byte(statsDocsKey), -1); err != nil {
+			return err
+		}
+		if err := addTotal(txn, []byte(statsTokensKey), -doc.WordCount); err != nil {
+			return err
+		}
+		if err := removeDocTerms(txn, docID); err != nil {
+			return err
+		}
+		for _, prefix := range []string{DocumentKeyPrefix, EmbeddingKeyPrefix, ChunksKeyPrefix} {
+			if err := txn.Delete([]byte(prefix + string(docID[:]))); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RebuildCorpusStats recomputes the corpus statistics from the stored documents and postings,
+// for indexes built before they were kept. It returns the number of documents counted.
+func (ir *IndexRepository) RebuildCorpusStats() (int, error) {
+	docs, tokens := 0, 0
+	records := map[[32]byte]*docTerms{}
+	err := ir.DB.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		prefix := []byte(DocumentKeyPrefix)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			val, err := it.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			doc, err := ir.bytesToDocument(val)
+			if err != nil {
+				return err
+			}
+			docs++
+			tokens += doc.WordCount
+		}
+		// postings start with a digit: <term id>_<doc id>_<frequency>, content hash keys may too
+		// but their empty values are no position list
+		for it.Seek([]byte("0")); it.Valid() && it.Item().Key()[0] <= '9'; it.Next() {
+			key := it.Item().Key()
+			term, rest, ok := cutTerm(key)
+			if !ok || len(rest) < 34 {
+				continue
+			}
+			freq, err := strconv.Atoi(string(rest[33:]))
+			if err != nil {
+				continue
+			}
+			positions := []model.Position{}
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &positions)
+			}); err != nil {
+				continue
+			}
+			id := [32]byte(rest[:32])
+			record := records[id]
+			if record == nil {
+				record = &docTerms{Terms: map[int]int{}, Fields: map[byte]int{}}
+				records[id] = record
+			}
+			record.Terms[term] = freq
+			for _, p := range positions {
+				record.Fields[p.Type]++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	ir.mu.Lock()
+	defer ir.mu.Unlock()
+	if err := ir.DB.DropPrefix([]byte(StatsKeyPrefix)); err != nil {
+		return 0, err
+	}
+	df, fields := map[int]int{}, map[byte]int{}
+	wb := ir.DB.NewWriteBatch()
+	defer wb.Cancel()
+	for id, record := range records {
+		encoded, err := json.Marshal(record)
+		if err != nil {
+			return 0, err
+		}
+		if err := wb.Set([]byte(statsTermsPrefix + string(id[:])), encoded); err != nil {
+			return 0, err
+		}
+		for term := range record.Terms {
+			df[term]++
+		}
+		for field, n := range record.Fields {
+			fields[field] += n
+		}
+	}
+	for term, n := range df {
+		if err := wb.Set(dfKey(term), []byte(strconv.Itoa(n))); err != nil {
+			return 0, err
+		}
+	}
+	for field, n := range fields {
+		if err := wb.Set(fieldKey(field), []byte(strconv.Itoa(n))); err != nil {
+			return 0, err
+		}
+	}
+	if err := wb.Set([]byte(statsDocsKey), []byte(strconv.Itoa(docs))); err != nil {
+		return 0, err
+	}
+	if err := wb.Set([]byte(statsTokensKey), []byte(strconv.Itoa(tokens))); err != nil {
+		return 0, err
+	}
+	if err := wb.Flush(); err != nil {
+		return 0, err
+	}
+	ir.generation.Add(1)
+	return docs, nil
+}
+
+// ensureCorpusStats rebuilds the corpus statistics of an index that has documents or words
+// but was built before the statistics were kept, so searches don't divide by zero documents.
+func (ir *IndexRepository) ensureCorpusStats() error {
+	missing := false
+	if err := ir.DB.View(func(txn *badger.Txn) error {
+		if _, err := txn.Get([]byte(statsDocsKey)); err != badger.ErrKeyNotFound {
+			return err
+		}
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for _, prefix := range []string{DocumentKeyPrefix, "word:"} {
+			if it.Seek([]byte(prefix)); it.ValidForPrefix([]byte(prefix)) {
+				missing = true
+				return nil
+			}
+		}
+		return nil
+	}); err != nil || !missing {
+		return err
+	}
+	_, err := ir.RebuildCorpusStats()
+	return err
+}
+
+// cutTerm splits a posting key into its term id and the rest after the separator.
+func cutTerm(key []byte) (int, []byte, bool) {
+	for i, c := range key {
+		if c == '_' {
+			term, err := strconv.Atoi(string(key[:i]))
+			return term, key[i + 1:], err == nil
+		}
+	}
+	return 0, nil, false
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"encoding/binary"
+
+	"github.com/dgraph-io/badger/v3"
+)
+
+const (
+	VectorMetaKey = "hnsw:meta"
+	VectorNodeKeyPrefix = "hnsw:node:"
+)
+
+// SaveVectorNodes writes changed graph nodes together with the graph metadata,
+// so a reloaded graph never points at nodes that were not stored.
+func (ir *IndexRepository) SaveVectorNodes(meta []byte, nodes map[uint64][]byte) error {
+	ir.mu.Lock()
+	defer ir.mu.Unlock()
+	wb := ir.DB.NewWriteBatch()
+	defer wb.Cancel()
+	for id, data := range nodes {
+		if err := wb.Set(binary.BigEndian.AppendUint64([]byte(VectorNodeKeyPrefix), id), data); err != nil {
+			return err
+		}
+	}
+	if err := wb.Set([]byte(VectorMetaKey), meta); err != nil {
+		return err
+	}
+	if err := wb.Flush(); err != nil {
+		return err
+	}
+	ir.generation.Add(1)
+	return nil
+}
+
+// LoadVectorIndex passes every stored node to the callback and returns the metadata,
+// nil metadata means that the graph was never built.
+func (ir *IndexRepository) LoadVectorIndex(node func(id uint64, data []byte) error) ([]byte, error) {
+	var meta []byte
+	return meta, ir.DB.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(VectorMetaKey))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if meta, err = item.ValueCopy(nil); err != nil {
+			return err
+		}
+
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(VectorNodeKeyPrefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			id := binary.BigEndian.Uint64(item.Key()[len(VectorNodeKeyPrefix):])
+			if err := item.Value(func(val []byte) error {
+				return node(id, val)
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (ir *IndexRepository) ClearVectorIndex() error {
+	ir.mu.Lock()
+	defer ir.mu.Unlock()
+	if err := ir.DB.DropPrefix([]byte("hnsw:")); err != nil {
+		return err
+	}
+	ir.generation.Add(1)
+	return nil
+}
@@ -0,0 +1,32 @@
+package bert
+
+import (
+	"encoding/json"
+	"os"
+)
+
+type Config struct {
+	HiddenSize            int     `json:"hidden_size"`
+	NumAttentionHeads     int     `json:"num_attention_heads"`
+	NumHiddenLayers       int     `json:"num_hidden_layers"`
+	IntermediateSize      int     `json:"intermediate_size"`
+	MaxPositionEmbeddings int     `json:"max_position_embeddings"`
+	TypeVocabSize         int     `json:"type_vocab_size"`
+	VocabSize             int     `json:"vocab_size"`
+	HiddenAct             string  `json:"hidden_act"`
+	LayerNormEps          float64 `json:"layer_norm_eps"`
+}
+
+func loadConfig(path string) (*Config, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	cfg := &Config{LayerNormEps: 1e-12, HiddenAct: "gelu"}
+	if err := json.NewDecoder(file).Decode(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
@@ -0,0 +1,291 @@
+package bert
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path/filepath"
+)
+
+type Options struct {
+	Pooling 	string // "mean" or "cls"
+	Normalize 	bool
+	MaxTokens 	int
+	Stride 		int
+}
+
+type linear struct {
+	w 		[]float32 // [out][in]
+	b 		[]float32
+	in, out int
+}
+
+type layerNorm struct {
+	gamma, beta []float32
+}
+
+type layer struct {
+	query, key, value 	linear
+	attnOut 			linear
+	attnNorm 			layerNorm
+	intermediate 		linear
+	output 				linear
+	outNorm 			layerNorm
+}
+
+type Model struct {
+	cfg 		*Config
+	opts 		Options
+	vocab 		*wordPiece
+	wordEmb 	*tensor
+	posEmb 		*tensor
+	typeEmb 	*tensor
+	embNorm 	layerNorm
+	layers 		[]layer
+}
+
+// Load reads config.json, vocab.txt and model.safetensors of a BERT encoder from dir.
+func Load(dir string, opts Options) (*Model, error) {
+	cfg, err := loadConfig(filepath.Join(dir, "config.json"))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.HiddenSize == 0 || cfg.NumAttentionHeads == 0 || cfg.HiddenSize % cfg.NumAttentionHeads != 0 {
+		return nil, fmt.Errorf("invalid config: hidden size %d, heads %d", cfg.HiddenSize, cfg.NumAttentionHeads)
+	}
+	vocab, err := loadVocab(filepath.Join(dir, "vocab.txt"))
+	if err != nil {
+		return nil, err
+	}
+	tensors, err := loadSafetensors(filepath.Join(dir, "model.safetensors"))
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.MaxTokens <= 2 || opts.MaxTokens > cfg.MaxPositionEmbeddings {
+		opts.MaxTokens = cfg.MaxPositionEmbeddings
+	}
+	if opts.Stride < 0 || opts.Stride >= opts.MaxTokens - 2 {
+		opts.Stride = 0
+	}
+	if opts.Pooling == "" {
+		opts.Pooling = "mean"
+	}
+	if opts.Pooling != "mean" && opts.Pooling != "cls" {
+		return nil, fmt.Errorf("unknown pooling: %q", opts.Pooling)
+	}
+
+	w := &weights{tensors: tensors}
+	m := &Model{cfg: cfg, opts: opts, vocab: vocab}
+	h := cfg.HiddenSize
+	m.wordEmb = w.get("embeddings.word_embeddings.weight", cfg.VocabSize, h)
+	m.posEmb = w.get("embeddings.position_embeddings.weight", cfg.MaxPositionEmbeddings, h)
+	m.typeEmb = w.get("embeddings.token_type_embeddings.weight", cfg.TypeVocabSize, h)
+	m.embNorm = w.norm("embeddings.LayerNorm", h)
+	for i := range cfg.NumHiddenLayers {
+		p := fmt.Sprintf("encoder.layer.%d.", i)
+		m.layers = append(m.layers, layer{
+			query: 			w.linear(p + "attention.self.query", h, h),
+			key: 			w.linear(p + "attention.self.key", h, h),
+			value: 			w.linear(p + "attention.self.value", h, h),
+			attnOut: 		w.linear(p + "attention.output.dense", h, h),
+			attnNorm: 		w.norm(p + "attention.output.LayerNorm", h),
+			intermediate: 	w.linear(p + "intermediate.dense", h, cfg.IntermediateSize),
+			output: 		w.linear(p + "output.dense", cfg.IntermediateSize, h),
+			outNorm: 		w.norm(p + "output.LayerNorm", h),
+		})
+	}
+	if w.err != nil {
+		return nil, w.err
+	}
+	return m, nil
+}
+
+func (m *Model) Dimension() int {
+	return m.cfg.HiddenSize
+}
+
+// Embed splits the text into overlapping windows of at most MaxTokens tokens
+// and returns one pooled vector per window.
+func (m *Model) Embed(ctx context.Context, text string) ([][]float64, error) {
+	ids := m.vocab.tokenize(text)
+	window := m.opts.MaxTokens - 2
+	step := window - m.opts.Stride
+
+	out := [][]float64{}
+	for start := 0; ; start += step {
+		end := min(start + window, len(ids))
+		seq := make([]int, 0, end - start + 2)
+		seq = append(seq, m.vocab.cls)
+		seq = append(seq, ids[start:end]...)
+		seq = append(seq, m.vocab.sep)
+
+		vec, err := m.forward(ctx, seq)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, vec)
+		if end == len(ids) {
+			break
+		}
+	}
+	return out, nil
+}
+
+func (m *Model) forward(ctx context.Context, ids []int) ([]float64, error) {
+	h := m.cfg.HiddenSize
+	n := len(ids)
+	x := make([]float32, n * h)
+	for i, id := range ids {
+		if id >= m.cfg.VocabSize {
+			id = m.vocab.unk
+		}
+		row := x[i * h:(i + 1) * h]
+		word := m.wordEmb.data[id * h:(id + 1) * h]
+		pos := m.posEmb.data[i * h:(i + 1) * h]
+		typ := m.typeEmb.data[:h]
+		for j := range row {
+			row[j] = word[j] + pos[j] + typ[j]
+		}
+	}
+	m.embNorm.apply(x, h, m.cfg.LayerNormEps)
+
+	for i := range m.layers {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+		x = m.layers[i].forward(x, n, m.cfg)
+	}
+
+	vec := make([]float64, h)
+	if m.opts.Pooling == "cls" {
+		for j := range vec {
+			vec[j] = float64(x[j])
+		}
+	} else {
+		for i := range n {
+			for j := range vec {
+				vec[j] += float64(x[i * h + j])
+			}
+		}
+		for j := range vec {
+			vec[j] /= float64(n)
+		}
+	}
+
+	if m.opts.Normalize {
+		norm := 0.0
+		for _, v := range vec {
+			norm += v * v
+		}
+		if norm = math.Sqrt(norm); norm > 0 {
+			for j := range vec {
+				vec[j] /= norm
+			}
+		}
+	}
+	return vec, nil
+}
+
+func (l *layer) forward(x []float32, n int, cfg *Config) []float32 {
+	h := cfg.HiddenSize
+	heads := cfg.NumAttentionHeads
+	d := h / heads
+	q := l.query.apply(x, n)
+	k := l.key.apply(x, n)
+	v := l.value.apply(x, n)
+
+	ctxVec := make([]float32, n * h)
+	scale := float32(1 / math.Sqrt(float64(d)))
+	parallel(n * heads, func(from, to int) {
+		scores := make([]float32, n)
+		for t := from; t < to; t++ {
+			i, head := t / heads, t % heads
+			qi := q[i * h + head * d:i * h + (head + 1) * d]
+			for j := range n {
+				scores[j] = dot(qi, k[j * h + head * d:j * h + (head + 1) * d]) * scale
+			}
+			softmax(scores)
+			out := ctxVec[i * h + head * d:i * h + (head + 1) * d]
+			for j := range n {
+				vj := v[j * h + head * d:j * h + (head + 1) * d]
+				for c := range out {
+					out[c] += scores[j] * vj[c]
+				}
+			}
+		}
+	})
+
+	attn := l.attnOut.apply(ctxVec, n)
+	for i := range attn {
+		attn[i] += x[i]
+	}
+	l.attnNorm.apply(attn, h, cfg.LayerNormEps)
+
+	inter := l.intermediate.apply(attn, n)
+	act := gelu
+	if cfg.HiddenAct == "gelu_new" || cfg.HiddenAct == "gelu_pytorch_tanh" {
+		act = geluTanh
+	}
+	for i := range inter {
+		inter[i] = act(inter[i])
+	}
+
+	out := l.output.apply(inter, n)
+	for i := range out {
+		out[i] += attn[i]
+	}
+	l.outNorm.apply(out, h, cfg.LayerNormEps)
+	return out
+}
+
+// weights resolves tensor names with or without the "bert." prefix and remembers the first error
+type weights struct {
+	tensors map[string]*tensor
+	err 	error
+}
+
+func (w *weights) get(name string, dims ...int) *tensor {
+	if w.err != nil {
+		return nil
+	}
+	t, ok := w.tensors[name]
+	if !ok {
+		t, ok = w.tensors["bert." + name]
+	}
+	if !ok {
+		w.err = fmt.Errorf("missing tensor %s", name)
+		return nil
+	}
+	if len(t.shape) != len(dims) {
+		w.err = fmt.Errorf("tensor %s: expected %d dims, got %v", name, len(dims), t.shape)
+		return nil
+	}
+	for i := range dims {
+		if t.shape[i] != dims[i] {
+			w.err = fmt.Errorf("tensor %s: expected shape %v, got %v", name, dims, t.shape)
+			return nil
+		}
+	}
+	return t
+}
+
+func (w *weights) linear(prefix string, in, out int) linear {
+	weight := w.get(prefix + ".weight", out, in)
+	bias := w.get(prefix + ".bias", out)
+	if w.err != nil {
+		return linear{}
+	}
+	return linear{w: weight.data, b: bias.data, in: in, out: out}
+}
+
+func (w *weights) norm(prefix string, h int) layerNorm {
+	gamma := w.get(prefix + ".weight", h)
+	beta := w.get(prefix + ".bias", h)
+	if w.err != nil {
+		return layerNorm{}
+	}
+	return layerNorm{gamma: gamma.data, beta: beta.data}
+}
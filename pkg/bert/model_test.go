@@ -0,0 +1,117 @@
+package bert
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"os"
+	"slices"
+	"testing"
+)
+
+// testdata holds a two layer BERT with random weights, expected.json its outputs from
+// a double precision Python forward pass, regenerated by testdata/generate.py.
+const tolerance = 1e-6
+
+type expected struct {
+	Text 	string 		`json:"text"`
+	Tokens 	[]int 		`json:"tokens"`
+	Mean 	[]float64 	`json:"mean"`
+	CLS 	[]float64 	`json:"cls"`
+	Windows struct {
+		MaxTokens 	int 		`json:"max_tokens"`
+		Stride 		int 		`json:"stride"`
+		Mean 		[][]float64 `json:"mean"`
+	} `json:"windows"`
+}
+
+func loadExpected(t *testing.T) *expected {
+	t.Helper()
+	raw, err := os.ReadFile("testdata/expected.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := &expected{}
+	if err := json.Unmarshal(raw, e); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func assertClose(t *testing.T, name string, got, want []float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: got %d values, want %d", name, len(got), len(want))
+	}
+	for i := range want {
+		if math.Abs(got[i] - want[i]) > tolerance {
+			t.Fatalf("%s[%d] = %v, want %v (within %v)", name, i, got[i], want[i], tolerance)
+		}
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	e := loadExpected(t)
+	m, err := Load("testdata", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.vocab.tokenize(e.Text); !slices.Equal(got, e.Tokens) {
+		t.Fatalf("tokenize(%q) = %v, want %v", e.Text, got, e.Tokens)
+	}
+}
+
+func TestEmbedMatchesReference(t *testing.T) {
+	e := loadExpected(t)
+	tests := []struct {
+		name 	string
+		opts 	Options
+		want 	[][]float64
+	}{
+		{"mean", Options{Pooling: "mean"}, [][]float64{e.Mean}},
+		{"cls", Options{Pooling: "cls"}, [][]float64{e.CLS}},
+		{"windows", Options{MaxTokens: e.Windows.MaxTokens, Stride: e.Windows.Stride}, e.Windows.Mean},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Load("testdata", tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := m.Embed(context.Background(), e.Text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d windows, want %d", len(got), len(tt.want))
+			}
+			for i := range tt.want {
+				assertClose(t, tt.name, got[i], tt.want[i])
+			}
+		})
+	}
+}
+
+func TestEmbedNormalize(t *testing.T) {
+	m, err := Load("testdata", Options{Normalize: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := m.Embed(context.Background(), loadExpected(t).Text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	norm := 0.0
+	for _, v := range got[0] {
+		norm += v * v
+	}
+	if math.Abs(norm - 1) > tolerance {
+		t.Fatalf("squared norm = %v, want 1", norm)
+	}
+}
+
+func TestLoadRejectsUnknownPooling(t *testing.T) {
+	if _, err := Load("testdata", Options{Pooling: "max"}); err == nil {
+		t.Fatal("Load accepted pooling max")
+	}
+}
@@ -0,0 +1,92 @@
+package bert
+
+import (
+	"math"
+	"runtime"
+	"sync"
+)
+
+func (l *linear) apply(x []float32, n int) []float32 {
+	y := make([]float32, n * l.out)
+	parallel(n, func(from, to int) {
+		for i := from; i < to; i++ {
+			xi := x[i * l.in:(i + 1) * l.in]
+			yi := y[i * l.out:(i + 1) * l.out]
+			for o := range yi {
+				yi[o] = l.b[o] + dot(xi, l.w[o * l.in:(o + 1) * l.in])
+			}
+		}
+	})
+	return y
+}
+
+func (ln *layerNorm) apply(x []float32, h int, eps float64) {
+	for i := 0; i < len(x); i += h {
+		row := x[i:i + h]
+		mean := 0.0
+		for _, v := range row {
+			mean += float64(v)
+		}
+		mean /= float64(h)
+		variance := 0.0
+		for _, v := range row {
+			d := float64(v) - mean
+			variance += d * d
+		}
+		inv := 1 / math.Sqrt(variance / float64(h) + eps)
+		for j := range row {
+			row[j] = float32((float64(row[j]) - mean) * inv) * ln.gamma[j] + ln.beta[j]
+		}
+	}
+}
+
+func dot(a, b []float32) float32 {
+	var s float32
+	for i := range a {
+		s += a[i] * b[i]
+	}
+	return s
+}
+
+func softmax(x []float32) {
+	maxV := x[0]
+	for _, v := range x {
+		maxV = max(maxV, v)
+	}
+	var sum float32
+	for i, v := range x {
+		x[i] = float32(math.Exp(float64(v - maxV)))
+		sum += x[i]
+	}
+	for i := range x {
+		x[i] /= sum
+	}
+}
+
+func gelu(x float32) float32 {
+	return float32(0.5 * float64(x) * (1 + math.Erf(float64(x) / math.Sqrt2)))
+}
+
+func geluTanh(x float32) float32 {
+	v := float64(x)
+	return float32(0.5 * v * (1 + math.Tanh(math.Sqrt(2 / math.Pi) * (v + 0.044715 * v * v * v))))
+}
+
+// parallel splits [0, n) into contiguous ranges processed by one goroutine per cpu
+func parallel(n int, fn func(from, to int)) {
+	workers := min(runtime.NumCPU(), n)
+	if workers <= 1 {
+		fn(0, n)
+		return
+	}
+	var wg sync.WaitGroup
+	size := (n + workers - 1) / workers
+	for from := 0; from < n; from += size {
+		wg.Add(1)
+		go func(from, to int) {
+			defer wg.Done()
+			fn(from, to)
+		}(from, min(from + size, n))
+	}
+	wg.Wait()
+}
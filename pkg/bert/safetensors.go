@@ -0,0 +1,114 @@
+package bert
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+)
+
+type tensorInfo struct {
+	Dtype       string  `json:"dtype"`
+	Shape       []int   `json:"shape"`
+	DataOffsets [2]int  `json:"data_offsets"`
+}
+
+type tensor struct {
+	shape []int
+	data  []float32
+}
+
+// loadSafetensors reads every tensor of a .safetensors file into float32 slices.
+func loadSafetensors(path string) (map[string]*tensor, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) < 8 {
+		return nil, fmt.Errorf("%s: file too short", path)
+	}
+	headerLen := binary.LittleEndian.Uint64(raw[:8])
+	if headerLen > uint64(len(raw) - 8) {
+		return nil, fmt.Errorf("%s: invalid header length", path)
+	}
+
+	header := map[string]json.RawMessage{}
+	if err := json.Unmarshal(raw[8:8 + headerLen], &header); err != nil {
+		return nil, err
+	}
+	data := raw[8 + headerLen:]
+
+	tensors := make(map[string]*tensor, len(header))
+	for name, msg := range header {
+		if name == "__metadata__" {
+			continue
+		}
+		var info tensorInfo
+		if err := json.Unmarshal(msg, &info); err != nil {
+			return nil, fmt.Errorf("tensor %s: %w", name, err)
+		}
+		start, end := info.DataOffsets[0], info.DataOffsets[1]
+		if start < 0 || end > len(data) || start > end {
+			return nil, fmt.Errorf("tensor %s: data offsets out of range", name)
+		}
+		values, err := decode(info.Dtype, data[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("tensor %s: %w", name, err)
+		}
+		count := 1
+		for _, d := range info.Shape {
+			count *= d
+		}
+		if count != len(values) {
+			return nil, fmt.Errorf("tensor %s: shape %v does not match %d values", name, info.Shape, len(values))
+		}
+		tensors[name] = &tensor{shape: info.Shape, data: values}
+	}
+	return tensors, nil
+}
+
+func decode(dtype string, b []byte) ([]float32, error) {
+	switch dtype {
+	case "F32":
+		out := make([]float32, len(b) / 4)
+		for i := range out {
+			out[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[i * 4:]))
+		}
+		return out, nil
+	case "F16":
+		out := make([]float32, len(b) / 2)
+		for i := range out {
+			out[i] = halfToFloat(binary.LittleEndian.Uint16(b[i * 2:]))
+		}
+		return out, nil
+	case "BF16":
+		out := make([]float32, len(b) / 2)
+		for i := range out {
+			out[i] = math.Float32frombits(uint32(binary.LittleEndian.Uint16(b[i * 2:])) << 16)
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("unsupported dtype %s", dtype)
+	}
+}
+
+func halfToFloat(h uint16) float32 {
+	sign := uint32(h >> 15) << 31
+	exp := uint32(h >> 10) & 0x1f
+	mant := uint32(h) & 0x3ff
+	switch {
+	case exp == 0 && mant == 0:
+		return math.Float32frombits(sign)
+	case exp == 0:
+		for mant & 0x400 == 0 {
+			mant <<= 1
+			exp--
+		}
+		exp++
+		mant &= 0x3ff
+	case exp == 0x1f:
+		return math.Float32frombits(sign | 0xff << 23 | mant << 13)
+	}
+	return math.Float32frombits(sign | (exp + 112) << 23 | mant << 13)
+}
@@ -0,0 +1,111 @@
+# Generates the tiny random BERT in this directory and expected.json, the outputs of a plain
+# Python forward pass over the same float32 weights. Run with python3 from any directory.
+import json, math, os, random, struct
+
+out = os.path.dirname(os.path.abspath(__file__))
+random.seed(1)
+H, NH, L, I, P = 8, 2, 2, 16, 16
+vocab = ["[PAD]", "[UNK]", "[CLS]", "[SEP]", "hello", "world", "the", "play", "##ing", "##ed", ",", "!", "cafe", "a"]
+V = len(vocab)
+with open(os.path.join(out, "vocab.txt"), "w") as f:
+    f.write("\n".join(vocab) + "\n")
+with open(os.path.join(out, "config.json"), "w") as f:
+    json.dump({"hidden_size": H, "num_attention_heads": NH, "num_hidden_layers": L, "intermediate_size": I,
+               "max_position_embeddings": P, "type_vocab_size": 2, "vocab_size": V, "hidden_act": "gelu",
+               "layer_norm_eps": 1e-12}, f)
+
+T = {}
+def rand(*shape):
+    n = 1
+    for d in shape:
+        n *= d
+    return (list(shape), [random.gauss(0, 0.5) for _ in range(n)])
+
+T["embeddings.word_embeddings.weight"] = rand(V, H)
+T["embeddings.position_embeddings.weight"] = rand(P, H)
+T["embeddings.token_type_embeddings.weight"] = rand(2, H)
+T["embeddings.LayerNorm.weight"] = rand(H)
+T["embeddings.LayerNorm.bias"] = rand(H)
+for l in range(L):
+    p = f"encoder.layer.{l}."
+    for n, (o, i) in {"attention.self.query": (H, H), "attention.self.key": (H, H), "attention.self.value": (H, H),
+                      "attention.output.dense": (H, H), "intermediate.dense": (I, H), "output.dense": (H, I)}.items():
+        T[p + n + ".weight"] = rand(o, i)
+        T[p + n + ".bias"] = rand(o)
+    for n in ["attention.output.LayerNorm", "output.LayerNorm"]:
+        T[p + n + ".weight"] = rand(H)
+        T[p + n + ".bias"] = rand(H)
+
+header, data = {}, b""
+for k, (shape, v) in T.items():
+    b = struct.pack("<%df" % len(v), *v)
+    header[k] = {"dtype": "F32", "shape": shape, "data_offsets": [len(data), len(data) + len(b)]}
+    data += b
+h = json.dumps(header).encode()
+with open(os.path.join(out, "model.safetensors"), "wb") as f:
+    f.write(struct.pack("<Q", len(h)) + h + data)
+
+# the reference runs in double precision on the weights rounded to float32
+W = {k: [struct.unpack("<f", struct.pack("<f", x))[0] for x in v] for k, (_, v) in T.items()}
+
+def linear(x, name, o, i):
+    w, b = W[name + ".weight"], W[name + ".bias"]
+    return [[b[a] + sum(row[k] * w[a * i + k] for k in range(i)) for a in range(o)] for row in x]
+
+def layer_norm(x, name):
+    g, b = W[name + ".weight"], W[name + ".bias"]
+    rows = []
+    for row in x:
+        m = sum(row) / len(row)
+        var = sum((v - m) ** 2 for v in row) / len(row)
+        rows.append([(v - m) / math.sqrt(var + 1e-12) * g[j] + b[j] for j, v in enumerate(row)])
+    return rows
+
+def forward(ids):
+    we, pe, te = W["embeddings.word_embeddings.weight"], W["embeddings.position_embeddings.weight"], W["embeddings.token_type_embeddings.weight"]
+    x = [[we[t * H + j] + pe[p * H + j] + te[j] for j in range(H)] for p, t in enumerate(ids)]
+    x = layer_norm(x, "embeddings.LayerNorm")
+    n, d = len(ids), H // NH
+    for l in range(L):
+        p = f"encoder.layer.{l}."
+        q = linear(x, p + "attention.self.query", H, H)
+        k = linear(x, p + "attention.self.key", H, H)
+        v = linear(x, p + "attention.self.value", H, H)
+        c = [[0.0] * H for _ in range(n)]
+        for hd in range(NH):
+            for i in range(n):
+                sc = [sum(q[i][hd * d + z] * k[j][hd * d + z] for z in range(d)) / math.sqrt(d) for j in range(n)]
+                mx = max(sc)
+                e = [math.exp(s - mx) for s in sc]
+                S = sum(e)
+                for j in range(n):
+                    for z in range(d):
+                        c[i][hd * d + z] += e[j] / S * v[j][hd * d + z]
+        a = linear(c, p + "attention.output.dense", H, H)
+        a = layer_norm([[a[i][j] + x[i][j] for j in range(H)] for i in range(n)], p + "attention.output.LayerNorm")
+        it = linear(a, p + "intermediate.dense", I, H)
+        it = [[0.5 * z * (1 + math.erf(z / math.sqrt(2))) for z in row] for row in it]
+        o = linear(it, p + "output.dense", H, I)
+        x = layer_norm([[o[i][j] + a[i][j] for j in range(H)] for i in range(n)], p + "output.LayerNorm")
+    return x
+
+def mean(x):
+    return [sum(row[j] for row in x) / len(x) for j in range(H)]
+
+text = "Hello, World! The playing café"
+# [CLS] hello , world ! the play ##ing cafe [SEP]
+tokens = [4, 10, 5, 11, 6, 7, 8, 12]
+full = forward([2] + tokens + [3])
+# windows of max_tokens - 2 = 4 tokens moving by 4 - stride = 3
+max_tokens, stride = 6, 1
+windows = []
+for start in range(0, len(tokens), 3):
+    end = min(start + 4, len(tokens))
+    windows.append(mean(forward([2] + tokens[start:end] + [3])))
+    if end == len(tokens):
+        break
+
+with open(os.path.join(out, "expected.json"), "w") as f:
+    json.dump({"text": text, "tokens": tokens, "mean": mean(full), "cls": full[0],
+               "windows": {"max_tokens": max_tokens, "stride": stride, "mean": windows}}, f, indent=1)
+    f.write("\n")
@@ -0,0 +1,147 @@
+package bert
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const maxWordChars = 100
+
+// wordPiece reproduces BertTokenizer with do_lower_case: basic cleanup and punctuation
+// splitting followed by greedy longest-match-first WordPiece.
+type wordPiece struct {
+	vocab map[string]int
+	unk   int
+	cls   int
+	sep   int
+}
+
+func loadVocab(path string) (*wordPiece, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	wp := &wordPiece{vocab: map[string]int{}}
+	scanner := bufio.NewScanner(file)
+	for i := 0; scanner.Scan(); i++ {
+		wp.vocab[strings.TrimRight(scanner.Text(), "\r")] = i
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	wp.unk = wp.vocab["[UNK]"]
+	wp.cls = wp.vocab["[CLS]"]
+	wp.sep = wp.vocab["[SEP]"]
+	return wp, nil
+}
+
+func (wp *wordPiece) tokenize(text string) []int {
+	ids := []int{}
+	for _, word := range basicTokenize(text) {
+		ids = append(ids, wp.pieces(word)...)
+	}
+	return ids
+}
+
+func (wp *wordPiece) pieces(word string) []int {
+	runes := []rune(word)
+	if len(runes) > maxWordChars {
+		return []int{wp.unk}
+	}
+
+	ids := []int{}
+	for start := 0; start < len(runes); {
+		end := len(runes)
+		found := -1
+		for ; end > start; end-- {
+			sub := string(runes[start:end])
+			if start > 0 {
+				sub = "##" + sub
+			}
+			if id, ok := wp.vocab[sub]; ok {
+				found = id
+				break
+			}
+		}
+		if found < 0 {
+			return []int{wp.unk}
+		}
+		ids = append(ids, found)
+		start = end
+	}
+	return ids
+}
+
+func basicTokenize(text string) []string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == 0 || r == unicode.ReplacementChar || isControl(r):
+		case unicode.IsSpace(r):
+			b.WriteRune(' ')
+		case isCJK(r):
+			b.WriteRune(' ')
+			b.WriteRune(r)
+			b.WriteRune(' ')
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	words := []string{}
+	for _, field := range strings.Fields(b.String()) {
+		field = stripAccents(strings.ToLower(field))
+		var cur strings.Builder
+		for _, r := range field {
+			if isPunct(r) {
+				if cur.Len() > 0 {
+					words = append(words, cur.String())
+					cur.Reset()
+				}
+				words = append(words, string(r))
+				continue
+			}
+			cur.WriteRune(r)
+		}
+		if cur.Len() > 0 {
+			words = append(words, cur.String())
+		}
+	}
+	return words
+}
+
+func stripAccents(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		if !unicode.Is(unicode.Mn, r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func isControl(r rune) bool {
+	if r == '\t' || r == '\n' || r == '\r' {
+		return false
+	}
+	return unicode.In(r, unicode.Cc, unicode.Cf)
+}
+
+func isPunct(r rune) bool {
+	if (r >= 33 && r <= 47) || (r >= 58 && r <= 64) || (r >= 91 && r <= 96) || (r >= 123 && r <= 126) {
+		return true
+	}
+	return unicode.IsPunct(r)
+}
+
+func isCJK(r rune) bool {
+	return (r >= 0x4E00 && r <= 0x9FFF) || (r >= 0x3400 && r <= 0x4DBF) || (r >= 0x20000 && r <= 0x2A6DF) ||
+		(r >= 0x2A700 && r <= 0x2B73F) || (r >= 0x2B740 && r <= 0x2B81F) || (r >= 0x2B820 && r <= 0x2CEAF) ||
+		(r >= 0xF900 && r <= 0xFAFF) || (r >= 0x2F800 && r <= 0x2FA1F)
+}
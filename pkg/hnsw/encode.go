@@ -0,0 +1,173 @@
+package hnsw
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+var ErrCorrupted = errors.New("hnsw: corrupted record")
+
+// EncodeMeta stores the graph entry point and id counter, nodes are encoded separately.
+func (idx *Index) EncodeMeta() []byte {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	buf := make([]byte, 0, 28)
+	buf = binary.BigEndian.AppendUint64(buf, idx.entry)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(int32(idx.maxLevel)))
+	buf = binary.BigEndian.AppendUint64(buf, idx.nextID)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(idx.dim))
+	buf = binary.BigEndian.AppendUint32(buf, uint32(idx.cfg.M))
+	return buf
+}
+
+func (idx *Index) DecodeMeta(data []byte) error {
+	if len(data) != 28 {
+		return ErrCorrupted
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.entry = binary.BigEndian.Uint64(data)
+	idx.maxLevel = int(int32(binary.BigEndian.Uint32(data[8:])))
+	idx.nextID = binary.BigEndian.Uint64(data[12:])
+	idx.dim = int(binary.BigEndian.Uint32(data[20:]))
+	if m := int(binary.BigEndian.Uint32(data[24:])); m != idx.cfg.M {
+		// links were pruned for another M, keep it so that the graph stays consistent
+		idx.cfg.M = m
+		idx.levelMult = 1 / math.Log(float64(m))
+	}
+	return nil
+}
+
+// EncodeNode layout: label length, label, dimension, float32 components,
+// level count and for every level the number of links followed by the ids.
+// Tombstones end with one more byte, nodes stored before deletion existed lack it.
+func (idx *Index) EncodeNode(id uint64) []byte {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	n, ok := idx.nodes[id]
+	if !ok {
+		return nil
+	}
+	buf := make([]byte, 0, 2 + len(n.label) + 4 + 4 * len(n.vec) + 1 + len(n.friends) * (2 + 8 * idx.cfg.M))
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(n.label)))
+	buf = append(buf, n.label...)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(n.vec)))
+	for _, v := range n.vec {
+		buf = binary.BigEndian.AppendUint32(buf, math.Float32bits(v))
+	}
+	buf = append(buf, byte(len(n.friends)))
+	for _, links := range n.friends {
+		buf = binary.BigEndian.AppendUint16(buf, uint16(len(links)))
+		for _, f := range links {
+			buf = binary.BigEndian.AppendUint64(buf, f)
+		}
+	}
+	if n.deleted {
+		buf = append(buf, 1)
+	}
+	return buf
+}
+
+func (idx *Index) DecodeNode(id uint64, data []byte) error {
+	r := reader{data: data}
+	n := &node{}
+	n.label = append([]byte(nil), r.next(int(r.uint16()))...)
+	n.vec = make([]float32, r.uint32())
+	for i := range n.vec {
+		n.vec[i] = math.Float32frombits(r.uint32())
+	}
+	n.friends = make([][]uint64, int(r.byte()))
+	for l := range n.friends {
+		n.friends[l] = make([]uint64, r.uint16())
+		for i := range n.friends[l] {
+			n.friends[l][i] = r.uint64()
+		}
+	}
+	if len(r.data) == 1 {
+		n.deleted = r.byte() == 1
+	}
+	if r.err || len(r.data) != 0 {
+		return ErrCorrupted
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if old, ok := idx.nodes[id]; ok && old.deleted {
+		idx.deleted--
+	}
+	if n.deleted {
+		idx.deleted++
+	}
+	idx.nodes[id] = n
+	return nil
+}
+
+// Validate checks that the entry point and every link refer to loaded nodes
+// and that all vectors have the dimension of the index.
+func (idx *Index) Validate() error {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	if len(idx.nodes) == 0 {
+		return nil
+	}
+	if _, ok := idx.nodes[idx.entry]; !ok {
+		return ErrCorrupted
+	}
+	for _, n := range idx.nodes {
+		if len(n.vec) != idx.dim {
+			return ErrCorrupted
+		}
+		for _, links := range n.friends {
+			for _, f := range links {
+				if _, ok := idx.nodes[f]; !ok {
+					return ErrCorrupted
+				}
+			}
+		}
+	}
+	return nil
+}
+
+type reader struct {
+	data 	[]byte
+	err 	bool
+}
+
+func (r *reader) next(n int) []byte {
+	if r.err || len(r.data) < n {
+		r.err = true
+		return nil
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *reader) byte() byte {
+	if b := r.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *reader) uint16() uint16 {
+	if b := r.next(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *reader) uint32() uint32 {
+	if b := r.next(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *reader) uint64() uint64 {
+	if b := r.next(8); b != nil {
+		return binary.BigEndian.Uint64(b)
+	}
+	return 0
+}
@@ -0,0 +1,376 @@
+package hnsw
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+)
+
+type Config struct {
+	M 				int
+	EfConstruction 	int
+	EfSearch 		int
+}
+
+type node struct {
+	label 	[]byte
+	vec 	[]float32
+	friends [][]uint64
+	deleted bool
+}
+
+// ErrDimension is returned for vectors whose dimension differs from the vectors already indexed.
+var ErrDimension = errors.New("hnsw: vector dimension mismatch")
+
+// Index is a hierarchical navigable small world graph over L2 normalized vectors
+// with cosine distance, labels identify what a vector belongs to. Deleted nodes stay
+// in the graph as tombstones that searches route through but never return.
+type Index struct {
+	mu 			sync.RWMutex
+	cfg 		Config
+	levelMult 	float64
+	rng 		*rand.Rand
+	nodes 		map[uint64]*node
+	entry 		uint64
+	maxLevel 	int
+	nextID 		uint64
+	dim 		int
+	deleted 	int
+}
+
+type Result struct {
+	Label 		[]byte
+	Similarity 	float64
+}
+
+func New(cfg Config) *Index {
+	if cfg.M < 2 {
+		cfg.M = 16
+	}
+	if cfg.EfConstruction < cfg.M {
+		cfg.EfConstruction = 200
+	}
+	if cfg.EfSearch <= 0 {
+		cfg.EfSearch = 64
+	}
+	return &Index{
+		cfg: 		cfg,
+		levelMult: 	1 / math.Log(float64(cfg.M)),
+		rng: 		rand.New(rand.NewSource(1)),
+		nodes: 		map[uint64]*node{},
+		maxLevel: 	-1,
+	}
+}
+
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.nodes)
+}
+
+func (idx *Index) SetEfSearch(ef int) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if ef > 0 {
+		idx.cfg.EfSearch = ef
+	}
+}
+
+// Insert adds a vector and returns its id with the ids of every node whose stored form changed,
+// the new one included, so that callers can persist only those.
+func (idx *Index) Insert(label []byte, vec []float64) (uint64, []uint64, error) {
+	q := normalize(vec)
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if err := idx.checkDim(len(q)); err != nil {
+		return 0, nil, err
+	}
+	id := idx.nextID
+	idx.nextID++
+	level := int(math.Floor(-math.Log(1 - idx.rng.Float64()) * idx.levelMult))
+	n := &node{label: append([]byte(nil), label...), vec: q, friends: make([][]uint64, level + 1)}
+	idx.nodes[id] = n
+	changed := map[uint64]struct{}{id: {}}
+
+	if idx.maxLevel < 0 {
+		idx.entry, idx.maxLevel, idx.dim = id, level, len(q)
+		return id, []uint64{id}, nil
+	}
+
+	ep := idx.entry
+	epDist := distance(q, idx.nodes[ep].vec)
+	for lc := idx.maxLevel; lc > level; lc-- {
+		ep, epDist = idx.greedy(q, ep, epDist, lc)
+	}
+
+	for lc := min(level, idx.maxLevel); lc >= 0; lc-- {
+		candidates := idx.searchLayer(q, []candidate{{id: ep, dist: epDist}}, idx.cfg.EfConstruction, lc)
+		neighbors := idx.selectNeighbors(candidates, idx.cfg.M)
+		for _, c := range neighbors {
+			n.friends[lc] = append(n.friends[lc], c.id)
+			other := idx.nodes[c.id]
+			other.friends[lc] = append(other.friends[lc], id)
+			if len(other.friends[lc]) > idx.maxConn(lc) {
+				idx.shrink(other, lc)
+			}
+			changed[c.id] = struct{}{}
+		}
+		ep, epDist = candidates[0].id, candidates[0].dist
+	}
+
+	if level > idx.maxLevel {
+		idx.entry, idx.maxLevel = id, level
+	}
+
+	ids := make([]uint64, 0, len(changed))
+	for c := range changed {
+		ids = append(ids, c)
+	}
+	return id, ids, nil
+}
+
+// Delete turns the node into a tombstone and reports whether it was live.
+func (idx *Index) Delete(id uint64) bool {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	n, ok := idx.nodes[id]
+	if !ok || n.deleted {
+		return false
+	}
+	n.deleted = true
+	idx.deleted++
+	return true
+}
+
+// Equal reports whether the node holds vec, up to the float32 precision vectors are kept in.
+func (idx *Index) Equal(id uint64, vec []float64) bool {
+	q := normalize(vec)
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	n, ok := idx.nodes[id]
+	if !ok || len(n.vec) != len(q) {
+		return false
+	}
+	for i := range q {
+		if n.vec[i] != q[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Search returns up to k nearest live vectors, most similar first.
+func (idx *Index) Search(vec []float64, k int) ([]Result, error) {
+	q := normalize(vec)
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	if idx.maxLevel < 0 {
+		return nil, nil
+	}
+	if err := idx.checkDim(len(q)); err != nil {
+		return nil, err
+	}
+
+	ep := idx.entry
+	epDist := distance(q, idx.nodes[ep].vec)
+	for lc := idx.maxLevel; lc > 0; lc-- {
+		ep, epDist = idx.greedy(q, ep, epDist, lc)
+	}
+	// tombstones take up room in the beam, it is widened by their share of the graph
+	ef := max(idx.cfg.EfSearch, k)
+	if live := len(idx.nodes) - idx.deleted; live > 0 && idx.deleted > 0 {
+		ef = ef * len(idx.nodes) / live
+	}
+	found := idx.searchLayer(q, []candidate{{id: ep, dist: epDist}}, ef, 0)
+
+	results := make([]Result, 0, min(k, len(found)))
+	for _, c := range found {
+		if len(results) == k {
+			break
+		}
+		if n := idx.nodes[c.id]; !n.deleted {
+			results = append(results, Result{Label: n.label, Similarity: 1 - float64(c.dist)})
+		}
+	}
+	return results, nil
+}
+
+func (idx *Index) checkDim(dim int) error {
+	if dim == 0 || (idx.dim != 0 && dim != idx.dim) {
+		return fmt.Errorf("%w: got %d, index holds %d", ErrDimension, dim, idx.dim)
+	}
+	return nil
+}
+
+func (idx *Index) maxConn(level int) int {
+	if level == 0 {
+		return 2 * idx.cfg.M
+	}
+	return idx.cfg.M
+}
+
+func (idx *Index) greedy(q []float32, ep uint64, epDist float32, level int) (uint64, float32) {
+	for changed := true; changed; {
+		changed = false
+		for _, f := range idx.nodes[ep].friends[level] {
+			if d := distance(q, idx.nodes[f].vec); d < epDist {
+				ep, epDist, changed = f, d, true
+			}
+		}
+	}
+	return ep, epDist
+}
+
+// searchLayer is the beam search of the paper, the result is sorted by distance
+func (idx *Index) searchLayer(q []float32, entries []candidate, ef int, level int) []candidate {
+	visited := map[uint64]struct{}{}
+	near := &minHeap{}
+	far := &maxHeap{}
+	for _, e := range entries {
+		visited[e.id] = struct{}{}
+		heap.Push(near, e)
+		heap.Push(far, e)
+	}
+
+	for near.Len() > 0 {
+		c := heap.Pop(near).(candidate)
+		if c.dist > far.minHeap[0].dist && far.Len() >= ef {
+			break
+		}
+		for _, f := range idx.nodes[c.id].friends[level] {
+			if _, ok := visited[f]; ok {
+				continue
+			}
+			visited[f] = struct{}{}
+			d := distance(q, idx.nodes[f].vec)
+			if far.Len() < ef || d < far.minHeap[0].dist {
+				heap.Push(near, candidate{id: f, dist: d})
+				heap.Push(far, candidate{id: f, dist: d})
+				if far.Len() > ef {
+					heap.Pop(far)
+				}
+			}
+		}
+	}
+
+	out := make([]candidate, far.Len())
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = heap.Pop(far).(candidate)
+	}
+	return out
+}
+
+// selectNeighbors keeps a candidate only when it is closer to the query than to every
+// neighbor already kept, which spreads links across clusters, and fills up with the rest.
+func (idx *Index) selectNeighbors(sorted []candidate, m int) []candidate {
+	kept := make([]candidate, 0, m)
+	pruned := []candidate{}
+	for _, c := range sorted {
+		if len(kept) >= m {
+			break
+		}
+		good := true
+		for _, k := range kept {
+			if distance(idx.nodes[c.id].vec, idx.nodes[k.id].vec) < c.dist {
+				good = false
+				break
+			}
+		}
+		if good {
+			kept = append(kept, c)
+		} else {
+			pruned = append(pruned, c)
+		}
+	}
+	for _, c := range pruned {
+		if len(kept) >= m {
+			break
+		}
+		kept = append(kept, c)
+	}
+	return kept
+}
+
+func (idx *Index) shrink(n *node, level int) {
+	candidates := make([]candidate, 0, len(n.friends[level]))
+	for _, f := range n.friends[level] {
+		candidates = append(candidates, candidate{id: f, dist: distance(n.vec, idx.nodes[f].vec)})
+	}
+	h := minHeap(candidates)
+	heap.Init(&h)
+	sorted := make([]candidate, 0, len(candidates))
+	for h.Len() > 0 {
+		sorted = append(sorted, heap.Pop(&h).(candidate))
+	}
+	kept := idx.selectNeighbors(sorted, idx.maxConn(level))
+	n.friends[level] = n.friends[level][:0]
+	for _, c := range kept {
+		n.friends[level] = append(n.friends[level], c.id)
+	}
+}
+
+func normalize(vec []float64) []float32 {
+	norm := 0.0
+	for _, v := range vec {
+		norm += v * v
+	}
+	norm = math.Sqrt(norm)
+	out := make([]float32, len(vec))
+	if norm == 0 {
+		return out
+	}
+	for i, v := range vec {
+		out[i] = float32(v / norm)
+	}
+	return out
+}
+
+func distance(a, b []float32) float32 {
+	var dot float32
+	for i := range a {
+		dot += a[i] * b[i]
+	}
+	return 1 - dot
+}
+
+type candidate struct {
+	id 		uint64
+	dist 	float32
+}
+
+type minHeap []candidate
+
+func (h minHeap) Len() int 				{ return len(h) }
+func (h minHeap) Less(i, j int) bool 	{ return h[i].dist < h[j].dist }
+func (h minHeap) Swap(i, j int) 		{ h[i], h[j] = h[j], h[i] }
+func (h *minHeap) Push(x any) 			{ *h = append(*h, x.(candidate)) }
+func (h *minHeap) Pop() any {
+	old := *h
+	x := old[len(old) - 1]
+	*h = old[:len(old) - 1]
+	return x
+}
+
+type maxHeap struct{ minHeap }
+
+func (h maxHeap) Less(i, j int) bool { return h.minHeap[i].dist > h.minHeap[j].dist }
+
+// Labels returns the labels of the live nodes by id.
+func (idx *Index) Labels() map[uint64][]byte {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	labels := make(map[uint64][]byte, len(idx.nodes) - idx.deleted)
+	for id, n := range idx.nodes {
+		if !n.deleted {
+			labels[id] = n.label
+		}
+	}
+	return labels
+}
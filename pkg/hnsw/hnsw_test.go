@@ -0,0 +1,222 @@
+package hnsw
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+const (
+	testDim = 32
+	testSize = 2000
+	testQueries = 100
+	testK = 10
+)
+
+func randomVectors(rng *rand.Rand, n int) [][]float64 {
+	vecs := make([][]float64, n)
+	for i := range vecs {
+		vecs[i] = make([]float64, testDim)
+		for j := range vecs[i] {
+			vecs[i][j] = rng.NormFloat64()
+		}
+	}
+	return vecs
+}
+
+func label(i int) []byte {
+	return binary.BigEndian.AppendUint32(nil, uint32(i))
+}
+
+func build(t testing.TB, vecs [][]float64) *Index {
+	t.Helper()
+	idx := New(Config{M: 16, EfConstruction: 200, EfSearch: 64})
+	for i, v := range vecs {
+		if _, _, err := idx.Insert(label(i), v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return idx
+}
+
+// bruteForce returns the labels of the k vectors most similar to q by cosine similarity.
+func bruteForce(vecs [][]float64, q []float64, k int, skip map[int]bool) []int {
+	nq := normalize(q)
+	type scored struct {
+		i 	int
+		d 	float32
+	}
+	all := make([]scored, 0, len(vecs))
+	for i, v := range vecs {
+		if !skip[i] {
+			all = append(all, scored{i, distance(nq, normalize(v))})
+		}
+	}
+	sort.Slice(all, func(a, b int) bool { return all[a].d < all[b].d })
+	out := make([]int, 0, k)
+	for _, s := range all[:min(k, len(all))] {
+		out = append(out, s.i)
+	}
+	return out
+}
+
+func recall(t *testing.T, idx *Index, vecs, queries [][]float64, skip map[int]bool) float64 {
+	t.Helper()
+	hit, total := 0, 0
+	for _, q := range queries {
+		results, err := idx.Search(q, testK)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := map[int]bool{}
+		for _, r := range results {
+			i := int(binary.BigEndian.Uint32(r.Label))
+			if skip[i] {
+				t.Fatalf("search returned deleted vector %d", i)
+			}
+			found[i] = true
+		}
+		for _, i := range bruteForce(vecs, q, testK, skip) {
+			total++
+			if found[i] {
+				hit++
+			}
+		}
+	}
+	return float64(hit) / float64(total)
+}
+
+func TestRecallAgainstBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	vecs := randomVectors(rng, testSize)
+	queries := randomVectors(rng, testQueries)
+	idx := build(t, vecs)
+
+	r := recall(t, idx, vecs, queries, nil)
+	t.Logf("recall@%d over %d random %d dimensional vectors: %.3f", testK, testSize, testDim, r)
+	if r < 0.9 {
+		t.Fatalf("recall@%d = %.3f, want at least 0.9", testK, r)
+	}
+}
+
+func TestRecallWithTombstones(t *testing.T) {
+	rng := rand.New(rand.NewSource(11))
+	vecs := randomVectors(rng, testSize)
+	queries := randomVectors(rng, testQueries)
+	idx := build(t, vecs)
+
+	deleted := map[int]bool{}
+	for i := 0; i < testSize; i += 4 {
+		if !idx.Delete(uint64(i)) {
+			t.Fatalf("Delete(%d) found no live node", i)
+		}
+		deleted[i] = true
+	}
+	if idx.Delete(0) {
+		t.Fatal("deleting a tombstone reported a live node")
+	}
+	if got, want := len(idx.Labels()), testSize - len(deleted); got != want {
+		t.Fatalf("Labels has %d live nodes, want %d", got, want)
+	}
+
+	r := recall(t, idx, vecs, queries, deleted)
+	t.Logf("recall@%d with a quarter of the nodes deleted: %.3f", testK, r)
+	if r < 0.9 {
+		t.Fatalf("recall@%d = %.3f, want at least 0.9", testK, r)
+	}
+}
+
+func TestDimensionMismatch(t *testing.T) {
+	idx := New(Config{})
+	if _, _, err := idx.Insert(label(0), nil); !errors.Is(err, ErrDimension) {
+		t.Fatalf("Insert of an empty vector: err = %v, want ErrDimension", err)
+	}
+	if _, _, err := idx.Insert(label(0), []float64{1, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := idx.Insert(label(1), []float64{1, 0}); !errors.Is(err, ErrDimension) {
+		t.Fatalf("Insert of a shorter vector: err = %v, want ErrDimension", err)
+	}
+	if _, err := idx.Search([]float64{1, 0, 0, 0}, 1); !errors.Is(err, ErrDimension) {
+		t.Fatalf("Search with a longer vector: err = %v, want ErrDimension", err)
+	}
+	if idx.Len() != 1 {
+		t.Fatalf("Len = %d after rejected inserts, want 1", idx.Len())
+	}
+}
+
+func TestEqual(t *testing.T) {
+	idx := New(Config{})
+	id, _, err := idx.Insert(label(0), []float64{3, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !idx.Equal(id, []float64{3, 4}) || !idx.Equal(id, []float64{6, 8}) {
+		t.Fatal("Equal rejected the inserted direction")
+	}
+	if idx.Equal(id, []float64{4, 3}) || idx.Equal(id + 1, []float64{3, 4}) {
+		t.Fatal("Equal accepted another vector or node")
+	}
+}
+
+func TestEncodeDecodeTombstones(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	vecs := randomVectors(rng, 50)
+	idx := build(t, vecs)
+	idx.Delete(5)
+
+	copyIdx := New(Config{M: 16})
+	if err := copyIdx.DecodeMeta(idx.EncodeMeta()); err != nil {
+		t.Fatal(err)
+	}
+	for id := range uint64(50) {
+		if err := copyIdx.DecodeNode(id, idx.EncodeNode(id)); err != nil {
+			t.Fatalf("node %d: %v", id, err)
+		}
+	}
+	if err := copyIdx.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := copyIdx.Labels()[5]; ok || len(copyIdx.Labels()) != 49 {
+		t.Fatal("decoded graph lost the tombstone")
+	}
+	for _, q := range vecs[:10] {
+		want, _ := idx.Search(q, 5)
+		got, err := copyIdx.Search(q, 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("decoded graph found %d results, want %d", len(got), len(want))
+		}
+		for i := range want {
+			if string(got[i].Label) != string(want[i].Label) {
+				t.Fatalf("decoded graph ranks %x at %d, want %x", got[i].Label, i, want[i].Label)
+			}
+		}
+	}
+}
+
+func BenchmarkSearch(b *testing.B) {
+	rng := rand.New(rand.NewSource(7))
+	idx := build(b, randomVectors(rng, testSize))
+	queries := randomVectors(rng, testQueries)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := idx.Search(queries[i % len(queries)], testK); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBruteForce(b *testing.B) {
+	rng := rand.New(rand.NewSource(7))
+	vecs := randomVectors(rng, testSize)
+	queries := randomVectors(rng, testQueries)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bruteForce(vecs, queries[i % len(queries)], testK, nil)
+	}
+}
@@ -0,0 +1,66 @@
+package lru
+
+import "testing"
+
+func TestEvictsLeastRecentlyUsed(t *testing.T) {
+	c := New[string, int](2)
+	c.Add("a", 1)
+	c.Add("b", 2)
+	// reading a makes b the least recently used
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Fatalf("Get(a) = %d, %v", v, ok)
+	}
+	c.Add("c", 3)
+	if _, ok := c.Get("b"); ok {
+		t.Fatal("b was not evicted")
+	}
+	for key, want := range map[string]int{"a": 1, "c": 3} {
+		if v, ok := c.Get(key); !ok || v != want {
+			t.Fatalf("Get(%s) = %d, %v, want %d", key, v, ok, want)
+		}
+	}
+	if c.Len() != 2 {
+		t.Fatalf("Len = %d, want 2", c.Len())
+	}
+}
+
+func TestAddReplaces(t *testing.T) {
+	c := New[string, int](2)
+	c.Add("a", 1)
+	c.Add("b", 2)
+	// replacing a refreshes it, so b goes next
+	c.Add("a", 10)
+	c.Add("c", 3)
+	if v, ok := c.Get("a"); !ok || v != 10 {
+		t.Fatalf("Get(a) = %d, %v, want 10", v, ok)
+	}
+	if _, ok := c.Get("b"); ok {
+		t.Fatal("b was not evicted")
+	}
+}
+
+func TestPurge(t *testing.T) {
+	c := New[int, string](4)
+	c.Add(1, "one")
+	c.Add(2, "two")
+	c.Purge()
+	if c.Len() != 0 {
+		t.Fatalf("Len after Purge = %d", c.Len())
+	}
+	if _, ok := c.Get(1); ok {
+		t.Fatal("purged key is still cached")
+	}
+	c.Add(3, "three")
+	if v, ok := c.Get(3); !ok || v != "three" {
+		t.Fatalf("Get(3) after Purge = %q, %v", v, ok)
+	}
+}
+
+func TestMinimalCapacity(t *testing.T) {
+	c := New[int, int](0)
+	c.Add(1, 1)
+	c.Add(2, 2)
+	if _, ok := c.Get(1); ok || c.Len() != 1 {
+		t.Fatalf("a cache of capacity 0 holds %d entries", c.Len())
+	}
+}
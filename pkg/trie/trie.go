@@ -0,0 +1,186 @@
+package trie
+
+import (
+	"container/heap"
+	"strings"
+	"sync"
+)
+
+// Trie is a compressed prefix tree of weighted keys safe for concurrent use. Every node keeps
+// the heaviest weight below it, so TopK never walks a subtree lighter than the keys it already has.
+type Trie struct {
+	mu 		sync.RWMutex
+	root 	*node
+	size 	int
+}
+
+type node struct {
+	label 		string
+	children 	[]*node
+	weight 		int
+	terminal 	bool
+	best 		int
+}
+
+// Entry is a key with its accumulated weight.
+type Entry struct {
+	Key 	string
+	Weight 	int
+}
+
+func New() *Trie {
+	return &Trie{root: &node{}}
+}
+
+func (t *Trie) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.size
+}
+
+// Add adds weight to key, keys are created with their first positive weight.
+func (t *Trie) Add(key string, weight int) {
+	if key == "" || weight <= 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	path := []*node{t.root}
+	n, rest := t.root, key
+	for rest != "" {
+		child := n.child(rest[0])
+		if child == nil {
+			child = &node{label: rest}
+			n.children = append(n.children, child)
+			n, rest = child, ""
+			path = append(path, n)
+			break
+		}
+		common := commonPrefix(child.label, rest)
+		if common < len(child.label) {
+			split := &node{label: child.label[:common], children: []*node{child}, best: child.best}
+			n.children[n.index(split.label[0])] = split
+			child.label = child.label[common:]
+			child = split
+		}
+		n, rest = child, rest[common:]
+		path = append(path, n)
+	}
+	if !n.terminal {
+		n.terminal = true
+		t.size++
+	}
+	n.weight += weight
+	for _, p := range path {
+		p.best = max(p.best, n.weight)
+	}
+}
+
+// Weight returns the weight of key and whether the trie holds it.
+func (t *Trie) Weight(key string) (int, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	n, rest := t.root, key
+	for rest != "" {
+		child := n.child(rest[0])
+		if child == nil || !strings.HasPrefix(rest, child.label) {
+			return 0, false
+		}
+		n, rest = child, rest[len(child.label):]
+	}
+	return n.weight, n.terminal
+}
+
+// TopK returns up to k keys starting with prefix, heaviest first and ties in key order.
+func (t *Trie) TopK(prefix string, k int) []Entry {
+	if k <= 0 {
+		return nil
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	n, rest, key := t.root, prefix, ""
+	for rest != "" {
+		child := n.child(rest[0])
+		if child == nil {
+			return nil
+		}
+		common := commonPrefix(child.label, rest)
+		if common < len(rest) && common < len(child.label) {
+			return nil
+		}
+		n, rest, key = child, rest[common:], key + child.label
+	}
+
+	q := &queue{{n: n, key: key, weight: n.best}}
+	var out []Entry
+	for q.Len() > 0 && len(out) < k {
+		it := heap.Pop(q).(item)
+		if it.n == nil {
+			out = append(out, Entry{Key: it.key, Weight: it.weight})
+			continue
+		}
+		if it.n.terminal {
+			heap.Push(q, item{key: it.key, weight: it.n.weight})
+		}
+		for _, c := range it.n.children {
+			heap.Push(q, item{n: c, key: it.key + c.label, weight: c.best})
+		}
+	}
+	return out
+}
+
+func (n *node) index(b byte) int {
+	for i, c := range n.children {
+		if c.label[0] == b {
+			return i
+		}
+	}
+	return -1
+}
+
+func (n *node) child(b byte) *node {
+	if i := n.index(b); i >= 0 {
+		return n.children[i]
+	}
+	return nil
+}
+
+func commonPrefix(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+// item is either a subtree, ranked by its heaviest key, or a finished key when n is nil.
+// Every key of a subtree starts with the subtree's key, so ordering ties by key keeps them in key order.
+type item struct {
+	n 		*node
+	key 	string
+	weight 	int
+}
+
+type queue []item
+
+func (q queue) Len() int { return len(q) }
+
+func (q queue) Less(i, j int) bool {
+	if q[i].weight != q[j].weight {
+		return q[i].weight > q[j].weight
+	}
+	return q[i].key < q[j].key
+}
+
+func (q queue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *queue) Push(x any) { *q = append(*q, x.(item)) }
+
+func (q *queue) Pop() any {
+	old := *q
+	it := old[len(old) - 1]
+	*q = old[:len(old) - 1]
+	return it
+}
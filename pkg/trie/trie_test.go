@@ -0,0 +1,101 @@
+package trie
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTopK(t *testing.T) {
+	tr := New()
+	for key, w := range map[string]int{"search": 5, "sea": 9, "seal": 2, "season": 5, "set": 1, "go": 7} {
+		tr.Add(key, w)
+	}
+	tests := []struct {
+		prefix 	string
+		k 		int
+		want 	[]Entry
+	}{
+		{"se", 10, []Entry{{"sea", 9}, {"search", 5}, {"season", 5}, {"seal", 2}, {"set", 1}}},
+		{"se", 2, []Entry{{"sea", 9}, {"search", 5}}},
+		// a prefix ending inside a node label
+		{"seas", 10, []Entry{{"season", 5}}},
+		{"sea", 3, []Entry{{"sea", 9}, {"search", 5}, {"season", 5}}},
+		{"", 2, []Entry{{"sea", 9}, {"go", 7}}},
+		{"x", 10, nil},
+		{"seax", 10, nil},
+		{"searches", 10, nil},
+		{"se", 0, nil},
+	}
+	for _, tt := range tests {
+		if got := tr.TopK(tt.prefix, tt.k); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("TopK(%q, %d) = %v, want %v", tt.prefix, tt.k, got, tt.want)
+		}
+	}
+}
+
+func TestAddAccumulates(t *testing.T) {
+	tr := New()
+	tr.Add("go", 1)
+	tr.Add("go", 2)
+	tr.Add("", 5)
+	tr.Add("rust", 0)
+	if w, ok := tr.Weight("go"); !ok || w != 3 {
+		t.Fatalf("Weight(go) = %d, %v, want 3", w, ok)
+	}
+	if tr.Len() != 1 {
+		t.Fatalf("Len = %d, want 1", tr.Len())
+	}
+	if _, ok := tr.Weight("rust"); ok {
+		t.Fatal("a key added without weight is held")
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tr := New()
+	tr.Add("romane", 1)
+	tr.Add("romanus", 2)
+	tr.Add("rom", 3)
+	tr.Add("rubens", 4)
+
+	// romanus splits romane after roman, rom splits roman and rubens splits rom after r
+	root := tr.root
+	if len(root.children) != 1 || root.children[0].label != "r" {
+		t.Fatalf("root children %v", labels(root))
+	}
+	r := root.children[0]
+	if got := labels(r); !reflect.DeepEqual(got, []string{"om", "ubens"}) {
+		t.Fatalf("children of r = %v", got)
+	}
+	rom := r.children[0]
+	if !rom.terminal || rom.weight != 3 || rom.best != 3 {
+		t.Fatalf("rom node: terminal %v weight %d best %d", rom.terminal, rom.weight, rom.best)
+	}
+	if got := labels(rom.children[0]); rom.children[0].label != "an" || !reflect.DeepEqual(got, []string{"e", "us"}) {
+		t.Fatalf("an node %q with children %v", rom.children[0].label, got)
+	}
+	if r.best != 4 || root.best != 4 {
+		t.Fatalf("best weights r %d, root %d, want 4", r.best, root.best)
+	}
+
+	for key, want := range map[string]int{"romane": 1, "romanus": 2, "rom": 3, "rubens": 4} {
+		if w, ok := tr.Weight(key); !ok || w != want {
+			t.Errorf("Weight(%q) = %d, %v, want %d", key, w, ok, want)
+		}
+	}
+	for _, key := range []string{"r", "roma", "roman", "romanes"} {
+		if _, ok := tr.Weight(key); ok {
+			t.Errorf("Weight(%q) reports a key that was never added", key)
+		}
+	}
+	if tr.Len() != 4 {
+		t.Fatalf("Len = %d, want 4", tr.Len())
+	}
+}
+
+func labels(n *node) []string {
+	var out []string
+	for _, c := range n.children {
+		out = append(out, c.label)
+	}
+	return out
+}